@@ -0,0 +1,61 @@
+package skyaway
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// How often the counted messages are written to the database.
+const activityFlushInterval = time.Minute
+
+type activityKey struct {
+	UserID int
+	Day    string
+}
+
+// The messages counted since the last flush, so that the group messages do
+// not each wait for the database.
+type activityCounts struct {
+	mu     sync.Mutex
+	counts map[activityKey]int
+}
+
+func newActivityCounts() *activityCounts {
+	return &activityCounts{counts: make(map[activityKey]int)}
+}
+
+// Counts one more message of the user for today.
+func (a *activityCounts) count(userID int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[activityKey{userID, time.Now().Format("2006-01-02")}]++
+}
+
+// Returns the counted messages and starts counting anew.
+func (a *activityCounts) take() map[activityKey]int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	counts := a.counts
+	a.counts = make(map[activityKey]int)
+	return counts
+}
+
+// Puts the counts back if they could not be written.
+func (a *activityCounts) restore(key activityKey, n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[key] += n
+}
+
+// Writes the counted messages to the database on the interval.
+func (bot *Bot) flushActivity() {
+	for range time.Tick(activityFlushInterval) {
+		for key, n := range bot.activity.take() {
+			if err := bot.db.AddMessages(key.UserID, key.Day, n); err != nil {
+				log.Printf("failed to save the messages of user %d: %v", key.UserID, err)
+				bot.activity.restore(key, n)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package skyaway
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// A skycoin address is base58 of 20 bytes of key hash, 1 byte of version and
+// 4 bytes of checksum.
+const (
+	addressKeyLen      = 20
+	addressChecksumLen = 4
+	addressLen         = addressKeyLen + 1 + addressChecksumLen
+)
+
+var InvalidAddress = errors.New("invalid skycoin address")
+var InvalidTxID = errors.New("invalid transaction id, expected 64 hex digits")
+
+// A transaction id is the hex of a sha256 hash.
+func validateTxID(txid string) error {
+	if b, err := hex.DecodeString(txid); err != nil || len(b) != sha256.Size {
+		return InvalidTxID
+	}
+	return nil
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := bytes.IndexRune([]byte(base58Alphabet), r)
+		if i < 0 {
+			return nil, InvalidAddress
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	decoded := n.Bytes()
+	// leading '1's stand for leading zero bytes
+	var zeros int
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	return append(make([]byte, zeros), decoded...), nil
+}
+
+// Checks that `addr` is a well-formed skycoin address with a valid checksum.
+func validateAddress(addr string) error {
+	b, err := decodeBase58(addr)
+	if err != nil {
+		return err
+	}
+	if len(b) != addressLen {
+		return InvalidAddress
+	}
+
+	version := b[addressKeyLen]
+	if version != 0 {
+		return InvalidAddress
+	}
+
+	sum := sha256.Sum256(b[:addressKeyLen+1])
+	if !bytes.Equal(sum[:addressChecksumLen], b[addressKeyLen+1:]) {
+		return InvalidAddress
+	}
+	return nil
+}
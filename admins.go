@@ -0,0 +1,155 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// How long the admin status asked from telegram is trusted.
+const adminRefreshInterval = 5 * time.Minute
+
+// When the admin status of the users was last asked from telegram.
+type adminChecks struct {
+	mu sync.Mutex
+	at map[int]time.Time
+}
+
+func newAdminChecks() *adminChecks {
+	return &adminChecks{at: make(map[int]time.Time)}
+}
+
+// Whether the status of the user should be asked again, and notes that it
+// is being asked now if so.
+func (c *adminChecks) due(id int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.at[id]) < adminRefreshInterval {
+		return false
+	}
+	c.at[id] = time.Now()
+	return true
+}
+
+func isChatAdmin(member tgbotapi.ChatMember) bool {
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// Whether the user is listed in `admins` of the config and thus stays an
+// admin no matter what.
+func (bot *Bot) isPermanentAdmin(id int) bool {
+	for _, admin := range bot.config.Admins {
+		if admin == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (bot *Bot) setAdmin(u *User, admin bool) error {
+	if u.Admin == admin {
+		return nil
+	}
+	u.Admin = admin
+	if err := bot.db.PutUser(u); err != nil {
+		return fmt.Errorf("failed to change user status: %v", err)
+	}
+	log.Printf("admin status of %s: %t", u.NameAndTags(), admin)
+	return nil
+}
+
+// Makes the administrators of the group and the permanent admins the bot
+// admins, and everyone else not. Does nothing unless `mirror_admins` is
+// set. Returns how many users were promoted and demoted.
+func (bot *Bot) mirrorAdmins() (int, int, error) {
+	if !bot.config.MirrorAdmins {
+		return 0, 0, nil
+	}
+
+	var members []tgbotapi.ChatMember
+	err := bot.call(0, PriorityChatter, func() (err error) {
+		members, err = bot.telegram.GetChatAdministrators(tgbotapi.ChatConfig{ChatID: bot.config.ChatID})
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get chat administrators from telegram: %v", err)
+	}
+
+	admins := make(map[int]bool)
+	for _, id := range bot.config.Admins {
+		admins[id] = true
+	}
+	for _, member := range members {
+		if member.User == nil || member.User.IsBot {
+			continue
+		}
+		admins[member.User.ID] = true
+		if bot.db.GetUser(member.User.ID) == nil {
+			u := &User{
+				ID:        member.User.ID,
+				UserName:  member.User.UserName,
+				FirstName: member.User.FirstName,
+				LastName:  member.User.LastName,
+			}
+			if err := bot.db.PutUser(u); err != nil {
+				return 0, 0, fmt.Errorf("failed to save the user: %v", err)
+			}
+		}
+	}
+
+	users, err := bot.db.GetAllUsers()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get users from db: %v", err)
+	}
+	var promoted, demoted int
+	for i := range users {
+		u := &users[i]
+		if u.Admin == admins[u.ID] {
+			continue
+		}
+		if err := bot.setAdmin(u, admins[u.ID]); err != nil {
+			return promoted, demoted, err
+		}
+		if u.Admin {
+			promoted++
+		} else {
+			demoted++
+		}
+	}
+	return promoted, demoted, nil
+}
+
+// Asks telegram whether the admin is still an administrator of the group
+// when the admins are mirrored. This is done before admin commands, at most
+// once per `adminRefreshInterval`, because the vendored telegram-bot-api v4
+// does not support the chat_member updates which would tell about promotions
+// and demotions. Only the admins and the permanent admins are asked about,
+// the new administrators of the group become admins with the next membership
+// sync.
+func (bot *Bot) refreshAdmin(u *User) error {
+	if !bot.config.MirrorAdmins {
+		return nil
+	}
+	if !u.Admin && !bot.isPermanentAdmin(u.ID) {
+		return nil
+	}
+	if !bot.adminChecks.due(u.ID) {
+		return nil
+	}
+
+	var member tgbotapi.ChatMember
+	err := bot.call(0, PriorityChatter, func() (err error) {
+		member, err = bot.telegram.GetChatMember(tgbotapi.ChatConfigWithUser{
+			ChatID: bot.config.ChatID,
+			UserID: u.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get chat member from telegram: %v", err)
+	}
+	return bot.setAdmin(u, isChatAdmin(member) || bot.isPermanentAdmin(u.ID))
+}
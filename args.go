@@ -0,0 +1,434 @@
+package skyaway
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bcampbell/fuzzytime"
+)
+
+type ArgType int
+
+const (
+	ArgWord     ArgType = iota // a single word
+	ArgText                    // the rest of the line
+	ArgInt                     // an integer number
+	ArgDuration                // "2h30m" or a number of hours
+	ArgTime                    // an ISO timestamp or a human readable time
+	ArgUser                    // a username or a user id
+	ArgAddress                 // a skycoin address
+	ArgEvent                   // an event id, "last" or "current"
+	ArgFlag                    // the name of the argument itself
+)
+
+// Describes a command argument. Optional arguments may be omitted, variadic
+// arguments consume one or more words of their type.
+type Arg struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	Variadic bool
+}
+
+// Parsed command arguments by name. Optional arguments which were not given
+// are absent.
+type Args map[string]interface{}
+
+func (a Args) Has(name string) bool {
+	_, ok := a[name]
+	return ok
+}
+
+func (a Args) Int(name string) int {
+	v, _ := a[name].(int)
+	return v
+}
+
+func (a Args) Duration(name string) Duration {
+	v, _ := a[name].(Duration)
+	return v
+}
+
+func (a Args) Time(name string) time.Time {
+	v, _ := a[name].(time.Time)
+	return v
+}
+
+func (a Args) User(name string) *User {
+	v, _ := a[name].(*User)
+	return v
+}
+
+func (a Args) Users(name string) []*User {
+	var users []*User
+	values, _ := a[name].([]interface{})
+	for _, v := range values {
+		users = append(users, v.(*User))
+	}
+	return users
+}
+
+func (a Args) Event(name string) *Event {
+	v, _ := a[name].(*Event)
+	return v
+}
+
+func (a Args) String(name string) string {
+	v, _ := a[name].(string)
+	return v
+}
+
+func (a Args) Strings(name string) []string {
+	var strs []string
+	values, _ := a[name].([]interface{})
+	for _, v := range values {
+		strs = append(strs, v.(string))
+	}
+	return strs
+}
+
+func (a Args) Bool(name string) bool {
+	v, _ := a[name].(bool)
+	return v
+}
+
+func (arg Arg) multiword() bool {
+	return arg.Variadic || arg.Type == ArgText || arg.Type == ArgTime
+}
+
+func (arg Arg) String() string {
+	var s string
+	if arg.Type == ArgFlag {
+		s = arg.Name
+	} else {
+		s = "<" + arg.Name + ">"
+	}
+	if arg.Variadic {
+		s += "..."
+	}
+	if arg.Optional {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// The minimum number of words needed to satisfy `args`.
+func minWords(args []Arg) int {
+	var n int
+	for _, arg := range args {
+		if !arg.Optional {
+			n++
+		}
+	}
+	return n
+}
+
+// Returns the first required argument which is left without a word if there
+// are only `n` words for `args`, or nil if there are enough words.
+func missingArg(args []Arg, n int) *Arg {
+	for i := range args {
+		if args[i].Optional {
+			continue
+		}
+		if n == 0 {
+			return &args[i]
+		}
+		n--
+	}
+	return nil
+}
+
+func (bot *Bot) parseArg(arg Arg, words []string) (interface{}, error) {
+	if arg.Variadic {
+		var values []interface{}
+		single := arg
+		single.Variadic = false
+		for _, word := range words {
+			v, err := bot.parseArg(single, []string{word})
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	text := strings.TrimSpace(strings.Join(words, ""))
+	switch arg.Type {
+	case ArgWord, ArgText:
+		return text, nil
+	case ArgInt:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s should be an integer number: %s", arg.Name, text)
+		}
+		return n, nil
+	case ArgDuration:
+		d, err := parseDuration(text)
+		if err != nil {
+			return nil, fmt.Errorf("malformed duration format: %s", text)
+		}
+		return NewDuration(d), nil
+	case ArgTime:
+		return parseTime(text)
+	case ArgUser:
+		// looked up once the words are matched, see resolveArg
+		if !isUserRef(strings.TrimPrefix(text, "@")) {
+			return nil, fmt.Errorf("expected a username or a user id: %s", text)
+		}
+		return text, nil
+	case ArgAddress:
+		if err := validateAddress(text); err != nil {
+			return nil, fmt.Errorf("%v: %s", err, text)
+		}
+		return text, nil
+	case ArgEvent:
+		// looked up once the words are matched, see resolveArg
+		if _, err := strconv.Atoi(text); err != nil && text != "last" && text != "current" {
+			return nil, fmt.Errorf("expected an event id, 'last' or 'current': %s", text)
+		}
+		return text, nil
+	case ArgFlag:
+		if !strings.EqualFold(text, arg.Name) {
+			return nil, fmt.Errorf("expected '%s', got '%s'", arg.Name, text)
+		}
+		return true, nil
+	default:
+		return nil, fmt.Errorf("unsupported argument type: %d", arg.Type)
+	}
+}
+
+// Telegram usernames and user ids consist of letters, digits and underscores.
+func isUserRef(text string) bool {
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// Looks up the users and the events the matched words refer to. This is done
+// after matching, so that the database is not queried for every span tried.
+func (bot *Bot) resolveArg(arg Arg, value interface{}) (interface{}, error) {
+	if arg.Variadic {
+		single := arg
+		single.Variadic = false
+		values := value.([]interface{})
+		for i, v := range values {
+			resolved, err := bot.resolveArg(single, v)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = resolved
+		}
+		return values, nil
+	}
+
+	text := value.(string)
+	switch arg.Type {
+	case ArgUser:
+		user := bot.db.GetUserByNameOrId(strings.TrimPrefix(text, "@"))
+		if user == nil {
+			return nil, fmt.Errorf("no user by that name or id: %s", text)
+		}
+		return user, nil
+	case ArgEvent:
+		return bot.db.FindEvent(text)
+	}
+	return value, nil
+}
+
+// Matches `words` against `specs` trying the longest spans first for
+// multiword arguments, and stores the values into `args`.
+func (bot *Bot) matchArgs(specs []Arg, words []string, args Args) error {
+	if len(specs) == 0 {
+		if len(words) > 0 {
+			return fmt.Errorf("unexpected argument: %s", strings.TrimSpace(words[0]))
+		}
+		return nil
+	}
+
+	if missing := missingArg(specs, len(words)); missing != nil {
+		return fmt.Errorf("missing argument: %s", missing.Name)
+	}
+
+	spec, rest := specs[0], specs[1:]
+
+	longest := len(words) - minWords(rest)
+	if !spec.multiword() && longest > 1 {
+		longest = 1
+	}
+	if longest < 0 {
+		longest = 0
+	}
+	shortest := 1
+	if spec.Optional {
+		shortest = 0
+	}
+
+	err := fmt.Errorf("missing argument: %s", spec.Name)
+	for n := longest; n >= shortest; n-- {
+		if n == 0 {
+			if err = bot.matchArgs(rest, words, args); err == nil {
+				return nil
+			}
+			continue
+		}
+
+		var value interface{}
+		if value, err = bot.parseArg(spec, words[:n]); err != nil {
+			continue
+		}
+		if err = bot.matchArgs(rest, words[n:], args); err == nil {
+			args[spec.Name] = value
+			return nil
+		}
+	}
+	return err
+}
+
+// Splits the text into words keeping the whitespace after each word, so that
+// multiword arguments could be joined back with the original line breaks.
+func splitWords(text string) []string {
+	var words []string
+	text = strings.TrimSpace(text)
+	for text != "" {
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			end = len(text)
+		}
+		next := strings.IndexFunc(text[end:], func(r rune) bool {
+			return !unicode.IsSpace(r)
+		})
+		if next < 0 {
+			next = len(text) - end
+		}
+		words = append(words, text[:end+next])
+		text = text[end+next:]
+	}
+	return words
+}
+
+func (bot *Bot) parseArgs(specs []Arg, text string) (Args, error) {
+	args := make(Args)
+	if err := bot.matchArgs(specs, splitWords(text), args); err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if spec.Type != ArgUser && spec.Type != ArgEvent || !args.Has(spec.Name) {
+			continue
+		}
+		value, err := bot.resolveArg(spec, args[spec.Name])
+		if err != nil {
+			return nil, err
+		}
+		args[spec.Name] = value
+	}
+	return args, nil
+}
+
+// Splits the text into "key=value" pairs. A value goes on until the next word
+// with '=', so that it could have spaces, like a human readable time.
+func parseKeyValues(text string, keys ...string) (map[string]string, error) {
+	values := make(map[string]string)
+	var key string
+	for _, word := range splitWords(text) {
+		i := strings.Index(word, "=")
+		if i < 0 {
+			if key == "" {
+				return nil, fmt.Errorf("expected key=value: %s", strings.TrimSpace(word))
+			}
+			values[key] += word
+			continue
+		}
+
+		key = strings.ToLower(word[:i])
+		known := false
+		for _, k := range keys {
+			known = known || k == key
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown key '%s', expected %s", key, strings.Join(keys, ", "))
+		}
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("'%s' given twice", key)
+		}
+		values[key] = word[i+1:]
+	}
+	for k, v := range values {
+		if values[k] = strings.TrimSpace(v); values[k] == "" {
+			return nil, fmt.Errorf("missing the value of '%s'", k)
+		}
+	}
+	return values, nil
+}
+
+func parseBool(text string) (bool, error) {
+	switch strings.ToLower(text) {
+	case "yes", "true", "on", "1":
+		return true, nil
+	case "no", "false", "off", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected yes or no: %s", text)
+}
+
+func parseDuration(args string) (time.Duration, error) {
+	hours, err := strconv.ParseFloat(args, 64)
+	if err == nil {
+		return time.Second * time.Duration(hours*3600), nil
+	}
+
+	return time.ParseDuration(args)
+}
+
+// Parses an ISO timestamp or a human readable time. If only the time of day
+// is given, the nearest such moment in the future is returned.
+func parseTime(timestr string) (time.Time, error) {
+	ft, _, _ := fuzzytime.Extract(timestr)
+	if ft.Empty() {
+		return time.Time{}, fmt.Errorf("unsupported datetime format: %v", timestr)
+	}
+
+	var hour, minute, second int
+	var loc *time.Location
+	if ft.Time.HasHour() {
+		hour = ft.Time.Hour()
+	}
+	if ft.Time.HasMinute() {
+		minute = ft.Time.Minute()
+	}
+	if ft.Time.HasSecond() {
+		second = ft.Time.Second()
+	}
+	if ft.Time.HasTZOffset() {
+		loc = time.FixedZone("", ft.Time.TZOffset())
+	} else {
+		loc = time.UTC
+	}
+
+	if ft.HasFullDate() {
+		return time.Date(
+			ft.Date.Year(),
+			time.Month(ft.Date.Month()),
+			ft.Date.Day(),
+			hour, minute, second, 0,
+			loc,
+		), nil
+	}
+
+	year, month, day := time.Now().In(loc).Date()
+	start := time.Date(
+		year, month, day,
+		hour, minute, second, 0,
+		loc,
+	)
+	if start.Before(time.Now()) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start, nil
+}
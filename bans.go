@@ -0,0 +1,58 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// How often the expired bans are looked for.
+const banCheckInterval = time.Minute
+
+// Describes the reason, the author and the remaining time of the ban for
+// the banned users list.
+func (bot *Bot) banDetails(tr Translator, u *User) string {
+	var details []string
+	if u.BanReason != "" {
+		details = append(details, tr.T("ban.reason", u.BanReason))
+	}
+	if u.BannedBy.Valid {
+		by := fmt.Sprint(u.BannedBy.Int64)
+		if admin := bot.db.GetUser(int(u.BannedBy.Int64)); admin != nil {
+			by = mention(admin)
+		}
+		details = append(details, tr.T("ban.by", by))
+	}
+	if u.BannedUntil.Valid {
+		left := time.Until(u.BannedUntil.Time).Truncate(time.Second)
+		if left < 0 {
+			left = 0
+		}
+		details = append(details, tr.T("ban.left", tr.Duration(left)))
+	} else {
+		details = append(details, tr.T("ban.permanent"))
+	}
+	return " — " + strings.Join(details, ", ")
+}
+
+// Unbans the users whose temporary bans have expired.
+func (bot *Bot) liftBans() {
+	for range time.Tick(banCheckInterval) {
+		users, err := bot.db.GetExpiredBans()
+		if err != nil {
+			log.Printf("failed to get expired bans: %v", err)
+			continue
+		}
+
+		for i := range users {
+			u := &users[i]
+			u.Unban()
+			if err := bot.db.PutUser(u); err != nil {
+				log.Printf("failed to lift the ban of %s: %v", u.NameAndTags(), err)
+				continue
+			}
+			log.Printf("ban expired: %s", u.NameAndTags())
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package skyaway
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// Handles a press of an inline button. Returns a text to be shown to the
+// user as a notification (may be empty).
+type CallbackHandler func(*Bot, *Context, string) (string, error)
+
+var BadCallbackData = errors.New("malformed or forged callback data")
+
+// Telegram does not allow callback data longer than this.
+const callbackDataLimit = 64
+
+// The number of bytes of hmac to keep in the callback data.
+const callbackSignatureLen = 8
+
+// How long a destructive command waits for the confirmation.
+const confirmationTimeout = 5 * time.Minute
+
+// A destructive command which waits for the user to press "Confirm".
+type confirmation struct {
+	command Command
+	ctx     *Context
+	expires time.Time
+}
+
+func (bot *Bot) SetCallbackHandler(name string, handler CallbackHandler) {
+	bot.callbackHandlers[name] = handler
+}
+
+func (bot *Bot) signCallback(name, payload string) string {
+	key := sha256.Sum256([]byte("callback:" + bot.config.Token))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(name + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSignatureLen])
+}
+
+// Encodes and signs callback data for the callback handler `name`, so that
+// the payload could not be forged by the client.
+func (bot *Bot) callbackData(name, payload string) (string, error) {
+	data := fmt.Sprintf("%s:%s:%s", name, payload, bot.signCallback(name, payload))
+	if len(data) > callbackDataLimit {
+		return "", fmt.Errorf("callback data is too long: %s", data)
+	}
+	return data, nil
+}
+
+func (bot *Bot) parseCallbackData(data string) (name, payload string, err error) {
+	first, last := strings.Index(data, ":"), strings.LastIndex(data, ":")
+	if first < 0 || first == last {
+		err = BadCallbackData
+		return
+	}
+
+	name, payload = data[:first], data[first+1:last]
+	signature := data[last+1:]
+	if !hmac.Equal([]byte(signature), []byte(bot.signCallback(name, payload))) {
+		err = BadCallbackData
+	}
+	return
+}
+
+func (bot *Bot) CallbackButton(text, name, payload string) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := bot.callbackData(name, payload)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, data), nil
+}
+
+// Replaces the text of the message with the buttons and removes the buttons.
+func (bot *Bot) EditCallbackMessage(ctx *Context, text string) error {
+	edit := tgbotapi.NewEditMessageText(ctx.message.Chat.ID, ctx.message.MessageID, text)
+	bot.post(ctx.message.Chat.ID, PriorityChatter, edit)
+	return nil
+}
+
+func (bot *Bot) handleCallbackQuery(ctx *Context) error {
+	query := ctx.callback
+	var notification string
+	var err error
+
+	tr := bot.Tr(ctx)
+	switch {
+	case ctx.message == nil:
+		err = errors.New(tr.T("callback.too_old"))
+	case ctx.User == nil || ctx.User.Banned:
+		err = errors.New(tr.T("callback.not_allowed"))
+	default:
+		notification, err = bot.routeCallback(ctx, query.Data)
+	}
+
+	if err != nil {
+		log.Printf("callback '%s' from %s failed: %v", query.Data, query.From.String(), err)
+		notification = tr.T("callback.failed", err)
+	}
+
+	return bot.call(0, PriorityChatter, func() error {
+		_, err := bot.telegram.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, notification))
+		return err
+	})
+}
+
+func (bot *Bot) routeCallback(ctx *Context, data string) (string, error) {
+	name, payload, err := bot.parseCallbackData(data)
+	if err != nil {
+		return "", err
+	}
+
+	handler, found := bot.callbackHandlers[name]
+	if !found {
+		return "", fmt.Errorf("callback handler not found: %s", name)
+	}
+	return handler(bot, ctx, payload)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Asks the user to confirm the command with inline buttons and remembers
+// the command to be run when confirmed. The arguments are parsed again then.
+func (bot *Bot) askConfirmation(ctx *Context, command Command) error {
+	now := time.Now()
+	for token, c := range bot.confirmations {
+		if now.After(c.expires) {
+			delete(bot.confirmations, token)
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	tr := bot.Tr(ctx)
+	confirm, err := bot.CallbackButton(tr.T("confirm.button"), "confirm", token)
+	if err != nil {
+		return err
+	}
+	cancel, err := bot.CallbackButton(tr.T("confirm.cancel_button"), "cancel", token)
+	if err != nil {
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(ctx.message.Chat.ID, tr.T("confirm.ask", ctx.message.Text))
+	msg.ReplyToMessageID = ctx.message.MessageID
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(confirm, cancel),
+	)
+	bot.post(ctx.message.Chat.ID, PriorityChatter, msg)
+
+	bot.confirmations[token] = &confirmation{
+		command: command,
+		ctx:     ctx,
+		expires: now.Add(confirmationTimeout),
+	}
+	return nil
+}
+
+// Takes the pending confirmation out of the list if it belongs to the user.
+func (bot *Bot) popConfirmation(ctx *Context, token string) (*confirmation, error) {
+	tr := bot.Tr(ctx)
+	c, found := bot.confirmations[token]
+	if !found || time.Now().After(c.expires) {
+		delete(bot.confirmations, token)
+		return nil, errors.New(tr.T("confirm.expired"))
+	}
+	if c.ctx.User.ID != ctx.User.ID {
+		return nil, errors.New(tr.T("confirm.not_yours"))
+	}
+	delete(bot.confirmations, token)
+	return c, nil
+}
+
+func (bot *Bot) handleCallbackConfirm(ctx *Context, token string) (string, error) {
+	c, err := bot.popConfirmation(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	tr := bot.Tr(ctx)
+
+	// the user and the arguments may have changed since the command was sent
+	u := bot.db.GetUser(ctx.User.ID)
+	if u == nil || u.Banned || (c.command.Admin && !u.Admin) {
+		return "", errors.New(tr.T("callback.not_allowed"))
+	}
+	c.ctx.User = u
+	args, err := bot.parseArgs(c.command.Args, c.ctx.message.CommandArguments())
+	if err != nil {
+		return "", bot.Reply(c.ctx, tr.T("command.failed", err))
+	}
+
+	if err := bot.EditCallbackMessage(ctx, tr.T("confirm.done", c.ctx.message.Text)); err != nil {
+		log.Printf("failed to edit confirmation message: %v", err)
+	}
+
+	if err := c.command.Handlerfunc(bot, c.ctx, args); err != nil {
+		log.Printf("command '%s' failed: %v", c.ctx.message.Text, err)
+		return "", bot.Reply(c.ctx, tr.T("command.failed", err))
+	}
+	return tr.T("done"), nil
+}
+
+func (bot *Bot) handleCallbackCancel(ctx *Context, token string) (string, error) {
+	c, err := bot.popConfirmation(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	tr := bot.Tr(ctx)
+	if err := bot.EditCallbackMessage(ctx, tr.T("confirm.cancelled_text", c.ctx.message.Text)); err != nil {
+		log.Printf("failed to edit confirmation message: %v", err)
+	}
+	return tr.T("confirm.cancelled"), nil
+}
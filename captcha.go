@@ -0,0 +1,258 @@
+package skyaway
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// The kinds of challenges new members can get.
+const (
+	CaptchaButton     = "button"     // press a button
+	CaptchaArithmetic = "arithmetic" // choose the sum of two numbers
+)
+
+var captchaKinds = []string{CaptchaButton, CaptchaArithmetic}
+
+// How long a new member has to pass the challenge unless configured with
+// `captcha_timeout`.
+const defaultCaptchaTimeout = 5 * time.Minute
+
+// How often the expired challenges are looked for.
+const captchaCheckInterval = 10 * time.Second
+
+// The number of answers offered in an arithmetic challenge.
+const captchaOptions = 4
+
+func isCaptchaKind(name string) bool {
+	for _, kind := range captchaKinds {
+		if kind == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (bot *Bot) captchaTimeout() time.Duration {
+	if bot.config.CaptchaTimeout.Valid {
+		return bot.config.CaptchaTimeout.Duration
+	}
+	return defaultCaptchaTimeout
+}
+
+// How to address the user in the group.
+func mention(u *User) string {
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	if u.FirstName != "" {
+		return u.FirstName
+	}
+	return strconv.Itoa(u.ID)
+}
+
+// Enlists the member of the group, or sends a challenge first if the
+// verification is required and the user has not passed it yet.
+func (bot *Bot) admitUser(u *User) error {
+	kinds, dm := bot.db.GetCaptcha(bot.config.ChatID)
+	if len(kinds) == 0 || u.Admin || u.Verification == VerificationPassed {
+		u.Enlisted = true
+		if err := bot.db.PutUser(u); err != nil {
+			return err
+		}
+		bot.recordReferral(u)
+		return nil
+	}
+	if u.Verification == VerificationPending {
+		return nil
+	}
+	return bot.challenge(u, kinds[randomIntn(len(kinds))], dm)
+}
+
+// Sends a challenge of the kind to the user, privately if `dm` is true and
+// the user can be messaged, and keeps the user unenlisted until it is passed.
+func (bot *Bot) challenge(u *User, kind string, dm bool) error {
+	tr := bot.groupTr()
+	timeout := bot.captchaTimeout()
+
+	var text, answer string
+	var options []string
+	switch kind {
+	case CaptchaButton:
+		text = tr.T("captcha.button", mention(u), tr.Duration(timeout))
+		answer = "ok"
+		options = []string{answer}
+	case CaptchaArithmetic:
+		a, b := 1+randomIntn(9), 1+randomIntn(9)
+		text = tr.T("captcha.arithmetic", mention(u), a, b, tr.Duration(timeout))
+		answer = strconv.Itoa(a + b)
+		options = []string{answer}
+		for len(options) < captchaOptions {
+			option := strconv.Itoa(2 + randomIntn(17))
+			unique := true
+			for _, o := range options {
+				unique = unique && o != option
+			}
+			if unique {
+				options = append(options, option)
+			}
+		}
+		for i := len(options) - 1; i > 0; i-- {
+			j := randomIntn(i + 1)
+			options[i], options[j] = options[j], options[i]
+		}
+	default:
+		return fmt.Errorf("unsupported challenge: %s", kind)
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, option := range options {
+		label := option
+		if kind == CaptchaButton {
+			label = tr.T("captcha.press")
+		}
+		button, err := bot.CallbackButton(label, "captcha", fmt.Sprintf("%d:%s", u.ID, option))
+		if err != nil {
+			return err
+		}
+		row = append(row, button)
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+
+	// the challenge is saved before it is sent, so that the answer could be
+	// checked however soon it comes
+	chatID := bot.config.ChatID
+	if dm {
+		chatID = int64(u.ID)
+	}
+	err := bot.db.PutChallenge(&Challenge{
+		UserID:    u.ID,
+		Answer:    answer,
+		ChatID:    chatID,
+		ExpiresAt: time.Now().Add(timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save the challenge: %v", err)
+	}
+	u.Enlisted = false
+	u.Verification = VerificationPending
+	if err := bot.db.PutUser(u); err != nil {
+		return fmt.Errorf("failed to mark the user pending: %v", err)
+	}
+
+	// joins come in bursts, so the update loop does not wait for the sending
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = markup
+	bot.postThen(chatID, PriorityChatter, msg, func(sent tgbotapi.Message, err error) {
+		if err != nil && dm {
+			log.Printf("failed to send the challenge privately to %s, sending to the group: %v", u.NameAndTags(), err)
+			msg := tgbotapi.NewMessage(bot.config.ChatID, text)
+			msg.ReplyMarkup = markup
+			sent, err = bot.send(msg.ChatID, PriorityChatter, msg)
+		}
+		if err != nil {
+			log.Printf("failed to send the challenge to %s: %v", u.NameAndTags(), err)
+			return
+		}
+		if err := bot.db.SetChallengeMessage(u.ID, sent.Chat.ID, sent.MessageID); err != nil {
+			log.Printf("failed to save the challenge message: %v", err)
+		}
+	})
+
+	return nil
+}
+
+func (bot *Bot) handleCallbackCaptcha(ctx *Context, payload string) (string, error) {
+	tr := bot.Tr(ctx)
+	parts := strings.SplitN(payload, ":", 2)
+	if len(parts) != 2 {
+		return "", BadCallbackData
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", BadCallbackData
+	}
+	if userID != ctx.User.ID {
+		return "", errors.New(tr.T("captcha.not_yours"))
+	}
+
+	c := bot.db.GetChallenge(userID)
+	if c == nil {
+		return "", errors.New(tr.T("captcha.expired"))
+	}
+	if err := bot.db.DeleteChallenge(userID); err != nil {
+		return "", fmt.Errorf("failed to delete the challenge: %v", err)
+	}
+
+	u := ctx.User
+	var text string
+	if parts[1] == c.Answer {
+		u.Verification = VerificationPassed
+		u.Enlisted = true
+		text = bot.groupTr().T("captcha.passed", mention(u))
+	} else {
+		u.Verification = VerificationFailed
+		text = bot.groupTr().T("captcha.failed", mention(u))
+	}
+	if err := bot.db.PutUser(u); err != nil {
+		return "", fmt.Errorf("failed to save the user: %v", err)
+	}
+	bot.recordReferral(u)
+	log.Printf("verification of %s: %s", u.NameAndTags(), u.Verification)
+	return "", bot.EditCallbackMessage(ctx, text)
+}
+
+// Marks the users who have not passed their challenges in time.
+func (bot *Bot) expireChallenges() {
+	for range time.Tick(captchaCheckInterval) {
+		challenges, err := bot.db.GetExpiredChallenges()
+		if err != nil {
+			log.Printf("failed to get expired challenges: %v", err)
+			continue
+		}
+
+		for _, c := range challenges {
+			if err := bot.db.DeleteChallenge(c.UserID); err != nil {
+				log.Printf("failed to delete the challenge: %v", err)
+				continue
+			}
+
+			u := bot.db.GetUser(c.UserID)
+			if u == nil {
+				continue
+			}
+			u.Verification = VerificationTimeout
+			if err := bot.db.PutUser(u); err != nil {
+				log.Printf("failed to save the user: %v", err)
+				continue
+			}
+			log.Printf("verification of %s: %s", u.NameAndTags(), u.Verification)
+
+			if c.MessageID == 0 {
+				// never sent
+				continue
+			}
+			edit := tgbotapi.NewEditMessageText(c.ChatID, c.MessageID, bot.groupTr().T("captcha.timed_out", mention(u)))
+			if _, err := bot.send(c.ChatID, PriorityChatter, edit); err != nil {
+				log.Printf("failed to edit the challenge: %v", err)
+			}
+		}
+	}
+}
+
+// Returns a uniform random number in [0, n). The challenges use the system
+// randomness, so that the answers could not be predicted.
+func randomIntn(n int) int {
+	v, err := cryptorand.Int(cryptorand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return int(v.Int64())
+}
@@ -0,0 +1,108 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// Claims the coins of the user in the event to the address and tells the
+// user the outcome.
+func (bot *Bot) claim(ctx *Context, event *Event, address string) (string, error) {
+	tr := bot.Tr(ctx)
+	coins, err := bot.db.GetCoinsToClaim(ctx.User, event)
+	switch err {
+	case nil:
+	case NotParticipating:
+		return tr.T("status.not_participating"), nil
+	case AlreadyClaimed:
+		return tr.T("status.claimed", tr.Coins(coins)), nil
+	default:
+		return "", fmt.Errorf("failed to get coins to claim: %v", err)
+	}
+
+	switch err := bot.db.ClaimCoins(ctx.User, event, address); err {
+	case nil:
+		held, err := bot.checkClaim(ctx.User, event, address)
+		if err != nil {
+			log.Printf("failed to check the claim of %s: %v", ctx.User.NameAndTags(), err)
+		}
+		if held {
+			return tr.T("claim.held", tr.Coins(coins), address), nil
+		}
+		return tr.T("claim.done", tr.Coins(coins), address), nil
+	case AlreadyClaimed:
+		return tr.T("status.claimed", tr.Coins(coins)), nil
+	default:
+		return "", fmt.Errorf("failed to claim coins: %v", err)
+	}
+}
+
+// Handles private messages during an event: an address claims the coins to
+// it, anything else gets an offer to claim to the saved address.
+func (bot *Bot) handleClaimMessage(ctx *Context, text string) (bool, error) {
+	event := bot.db.GetCurrentEvent()
+	if event == nil || !event.StartedAt.Valid {
+		return true, nil
+	}
+
+	tr := bot.Tr(ctx)
+	address := strings.TrimSpace(text)
+	if validateAddress(address) == nil {
+		reply, err := bot.claim(ctx, event, address)
+		if err != nil {
+			return false, err
+		}
+		return false, bot.Reply(ctx, reply)
+	}
+
+	coins, err := bot.db.GetCoinsToClaim(ctx.User, event)
+	switch err {
+	case nil:
+	case NotParticipating:
+		return false, bot.Reply(ctx, tr.T("status.not_participating"))
+	case AlreadyClaimed:
+		return false, bot.Reply(ctx, tr.T("status.claimed", tr.Coins(coins)))
+	default:
+		return false, fmt.Errorf("failed to get coins to claim: %v", err)
+	}
+
+	if ctx.User.Address == "" {
+		return false, bot.Reply(ctx, tr.T("claim.send_address", tr.Coins(coins)))
+	}
+
+	button, err := bot.CallbackButton(tr.T("claim.use_saved"), "claim", strconv.Itoa(event.ID))
+	if err != nil {
+		return false, err
+	}
+	msg := tgbotapi.NewMessage(ctx.message.Chat.ID, tr.T("claim.offer", tr.Coins(coins), ctx.User.Address))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+	bot.post(msg.ChatID, PriorityChatter, msg)
+	return false, nil
+}
+
+func (bot *Bot) handleCallbackClaim(ctx *Context, payload string) (string, error) {
+	tr := bot.Tr(ctx)
+	eventID, err := strconv.Atoi(payload)
+	if err != nil {
+		return "", BadCallbackData
+	}
+
+	event := bot.db.GetCurrentEvent()
+	if event == nil || event.ID != eventID || !event.StartedAt.Valid {
+		return "", errors.New(tr.T("claim.event_over"))
+	}
+	if ctx.User.Address == "" {
+		return "", errors.New(tr.T("address.none"))
+	}
+
+	reply, err := bot.claim(ctx, event, ctx.User.Address)
+	if err != nil {
+		return "", err
+	}
+	return "", bot.EditCallbackMessage(ctx, reply)
+}
@@ -1,122 +1,546 @@
 package skyaway
 
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// A handler for a command with arguments already parsed according to the
+// command description.
+type ArgsHandler func(*Bot, *Context, Args) error
+
 type Command struct {
 	Admin       bool
 	Command     string
-	Handlerfunc CommandHandler
+	Args        []Arg
+	Description string
+	Handlerfunc ArgsHandler
+	Confirm     bool // ask for confirmation before running
 }
 
 type Commands []Command
 
+func (c *Command) Usage() string {
+	words := []string{"/" + c.Command}
+	for _, arg := range c.Args {
+		words = append(words, arg.String())
+	}
+	return strings.Join(words, " ")
+}
+
+// Wraps the command into a `CommandHandler` which parses the arguments and
+// complains about the usage if they do not match. Commands which need a
+// confirmation are postponed until the user presses the button.
+func (c Command) handler() CommandHandler {
+	return func(bot *Bot, ctx *Context, command, text string) error {
+		args, err := bot.parseArgs(c.Args, text)
+		if err != nil {
+			return fmt.Errorf("%v\n%s", err, bot.Tr(ctx).T("command.usage", c.Usage()))
+		}
+		if c.Confirm {
+			return bot.askConfirmation(ctx, c)
+		}
+		return c.Handlerfunc(bot, ctx, args)
+	}
+}
+
 func (bot *Bot) setCommandHandlers() {
-	for _, command := range commands {
-		bot.SetCommandHandler(command.Admin, command.Command, command.Handlerfunc)
+	bot.commands = commands
+	for _, command := range bot.commands {
+		bot.SetCommandHandler(command.Admin, command.Command, command.handler())
 	}
 
+	bot.SetCallbackHandler("confirm", (*Bot).handleCallbackConfirm)
+	bot.SetCallbackHandler("cancel", (*Bot).handleCallbackCancel)
+	bot.SetCallbackHandler("page", (*Bot).handleCallbackPage)
+	bot.SetCallbackHandler("claim", (*Bot).handleCallbackClaim)
+	bot.SetCallbackHandler("captcha", (*Bot).handleCallbackCaptcha)
+	bot.SetCallbackHandler("ban", (*Bot).handleCallbackBan)
+	bot.SetCallbackHandler("release", (*Bot).handleCallbackRelease)
+	bot.SetCallbackHandler("quiz", (*Bot).handleCallbackQuiz)
+	bot.SetCallbackHandler("join", (*Bot).handleCallbackJoin)
+
 	bot.AddPrivateMessageHandler((*Bot).handleDirectMessageFallback)
+	bot.AddPrivateMessageHandler((*Bot).handleClaimMessage)
+	bot.AddPrivateMessageHandler((*Bot).handleQuizMessage)
+	bot.AddPrivateMessageHandler((*Bot).handleRegistrationMessage)
 	bot.AddGroupMessageHandler((*Bot).handleDirectMessageFallback)
+	bot.AddGroupMessageHandler((*Bot).handleQuizMessage)
+}
+
+// Generates the help text. Admin commands are only listed if `admin` is true.
+func (bot *Bot) helpText(tr Translator, admin bool) string {
+	var lines []string
+	for i := range bot.commands {
+		command := &bot.commands[i]
+		if command.Admin && !admin {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s - %s", command.Usage(), tr.Describe(command)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Publishes the list of public commands to telegram in every supported
+// language, so that clients could suggest them.
+func (bot *Bot) publishCommands() error {
+	type botCommand struct {
+		Command     string `json:"command"`
+		Description string `json:"description"`
+	}
+
+	for _, lang := range supportedLanguages() {
+		tr := Translator{lang}
+		var list []botCommand
+		for i := range bot.commands {
+			command := &bot.commands[i]
+			if command.Admin {
+				continue
+			}
+			list = append(list, botCommand{command.Command, tr.Describe(command)})
+		}
+
+		encoded, err := json.Marshal(list)
+		if err != nil {
+			return fmt.Errorf("failed to encode commands into json: %v", err)
+		}
+
+		params := url.Values{"commands": {string(encoded)}}
+		if lang != defaultLanguage {
+			params.Set("language_code", lang)
+		}
+		err = bot.call(0, PriorityChatter, func() error {
+			_, err := bot.telegram.MakeRequest("setMyCommands", params)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set commands for '%s': %v", lang, err)
+		}
+		log.Printf("published %d commands for '%s'", len(list), lang)
+	}
+	return nil
+}
+
+// Filters and sorting of the commands listing users.
+var listOptions = []Arg{
+	{Name: "options", Type: ArgWord, Optional: true, Variadic: true},
 }
 
 var commands = Commands{
-	Command{
-		false,
-		"help",
-		(*Bot).handleCommandHelp,
-	},
-	Command{
-		false,
-		"start",
-		(*Bot).handleCommandStart,
-	},
-	Command{
-		true,
-		"adduser",
-		(*Bot).handleCommandAddUser,
-	},
-	Command{
-		true,
-		"makeadmin",
-		(*Bot).handleCommandMakeAdmin,
-	},
-	Command{
-		true,
-		"removeadmin",
-		(*Bot).handleCommandRemoveAdmin,
-	},
-	Command{
-		true,
-		"announce",
-		(*Bot).handleCommandAnnounce,
-	},
-	Command{
-		true,
-		"announceevent",
-		(*Bot).handleCommandAnnounceEvent,
-	},
-	Command{
-		false,
-		"listevent",
-		(*Bot).handleCommandListEvent,
-	},
-	Command{
-		true,
-		"banuser",
-		(*Bot).handleCommandBanUser,
-	},
-	Command{
-		true,
-		"unbanuser",
-		(*Bot).handleCommandUnBanUser,
-	},
-	Command{
-		true,
-		"cancelevent",
-		(*Bot).handleCommandCancelEvent,
-	},
-	Command{
-		true,
-		"scheduleevent",
-		(*Bot).handleCommandScheduleEvent,
-	},
-	Command{
-		true,
-		"settings",
-		(*Bot).handleCommandSettings,
-	},
-	Command{
-		true,
-		"startevent",
-		(*Bot).handleCommandStartEvent,
-	},
-	Command{
-		true,
-		"stopevent",
-		(*Bot).handleCommandStopEvent,
-	},
-	Command{
-		true,
-		"usercount",
-		(*Bot).handleCommandUserCount,
-	},
-	Command{
-		true,
-		"users",
-		func(bot *Bot, ctx *Context, command, args string) error {
+	{
+		Command: "start",
+		Args: []Arg{
+			{Name: "payload", Type: ArgWord, Optional: true},
+		},
+		Description: "greet the bot",
+		Handlerfunc: (*Bot).handleCommandStart,
+	},
+	{
+		Command:     "help",
+		Description: "this text",
+		Handlerfunc: (*Bot).handleCommandHelp,
+	},
+	{
+		Command: "language",
+		Args: []Arg{
+			{Name: "language", Type: ArgWord, Optional: true},
+		},
+		Description: "show or choose your language",
+		Handlerfunc: (*Bot).handleCommandLanguage,
+	},
+	{
+		Admin:   true,
+		Command: "chatlanguage",
+		Args: []Arg{
+			{Name: "language", Type: ArgWord},
+		},
+		Description: "choose the language of the group",
+		Handlerfunc: (*Bot).handleCommandChatLanguage,
+	},
+	{
+		Admin:       true,
+		Command:     "settings",
+		Description: "show the bot and chat settings",
+		Handlerfunc: (*Bot).handleCommandSettings,
+	},
+	{
+		Admin:   true,
+		Command: "captcha",
+		Args: []Arg{
+			{Name: "off|button|arithmetic|dm|group", Type: ArgWord, Optional: true, Variadic: true},
+		},
+		Description: "choose the challenges new members should pass to be enlisted",
+		Handlerfunc: (*Bot).handleCommandCaptcha,
+	},
+	{
+		Admin:   true,
+		Command: "scheduleevent",
+		Args: []Arg{
+			{Name: "coins", Type: ArgInt},
+			{Name: "start", Type: ArgTime},
+			{Name: "duration", Type: ArgDuration},
+			{Name: "surprise", Type: ArgFlag, Optional: true},
+		},
+		Description: "schedule an event at ISO timestamp or human readable time with duration in hours",
+		Handlerfunc: (*Bot).handleCommandScheduleEvent,
+	},
+	{
+		Admin:   true,
+		Command: "editevent",
+		Args: []Arg{
+			{Name: "event", Type: ArgEvent, Optional: true},
+			{Name: "changes", Type: ArgText},
+		},
+		Description: "change the scheduled event with coins=, start=, duration= or surprise=yes|no",
+		Handlerfunc: (*Bot).handleCommandEditEvent,
+	},
+	{
+		Admin:   true,
+		Command: "setquiz",
+		Args: []Arg{
+			{Name: "winners", Type: ArgInt},
+			{Name: "exact|regex|choice", Type: ArgWord},
+			{Name: "answer_for", Type: ArgDuration},
+			{Name: "question and answers", Type: ArgText},
+		},
+		Description: "make the scheduled event a quiz: the question and the answers go on separate lines, correct choices are marked with '*', 0 winners splits the coins among all correct answers",
+		Handlerfunc: (*Bot).handleCommandSetQuiz,
+	},
+	{
+		Admin:       true,
+		Command:     "removequiz",
+		Description: "make the scheduled event a regular one",
+		Handlerfunc: (*Bot).handleCommandRemoveQuiz,
+	},
+	{
+		Admin:   true,
+		Command: "setregistration",
+		Args: []Arg{
+			{Name: "window", Type: ArgDuration},
+			{Name: "keyword", Type: ArgText, Optional: true},
+		},
+		Description: "make only the users who join with a button or the keyword during the window before the start participate in the scheduled event",
+		Handlerfunc: (*Bot).handleCommandSetRegistration,
+	},
+	{
+		Admin:       true,
+		Command:     "removeregistration",
+		Description: "make all enlisted users participate in the scheduled event",
+		Handlerfunc: (*Bot).handleCommandRemoveRegistration,
+	},
+	{
+		Admin:   true,
+		Command: "setrollover",
+		Args: []Arg{
+			{Name: "wallet|next|claimers", Type: ArgWord, Optional: true},
+		},
+		Description: "keep the coins left unclaimed in the current event in the wallet, add them to the next event or split them among the claimers in a second round",
+		Handlerfunc: (*Bot).handleCommandSetRollover,
+	},
+	{
+		Admin:       true,
+		Command:     "cancelevent",
+		Description: "cancel a scheduled event",
+		Handlerfunc: (*Bot).handleCommandCancelEvent,
+		Confirm:     true,
+	},
+	{
+		Admin:       true,
+		Command:     "stopevent",
+		Description: "stop current event",
+		Handlerfunc: (*Bot).handleCommandStopEvent,
+		Confirm:     true,
+	},
+	{
+		Admin:   true,
+		Command: "startevent",
+		Args: []Arg{
+			{Name: "coins", Type: ArgInt},
+			{Name: "duration", Type: ArgDuration},
+		},
+		Description: "start an event immediately",
+		Handlerfunc: (*Bot).handleCommandStartEvent,
+	},
+	{
+		Command:     "mystatus",
+		Description: "tell whether you take part in the giveaways and the current event",
+		Handlerfunc: (*Bot).handleCommandMyStatus,
+	},
+	{
+		Command:     "history",
+		Description: "list your participations in the past events",
+		Handlerfunc: (*Bot).handleCommandHistory,
+	},
+	{
+		Command: "setaddress",
+		Args: []Arg{
+			{Name: "address", Type: ArgAddress},
+		},
+		Description: "save your default payout address",
+		Handlerfunc: (*Bot).handleCommandSetAddress,
+	},
+	{
+		Command: "leaderboard",
+		Args: []Arg{
+			{Name: "coins|claims|streak", Type: ArgWord, Optional: true},
+			{Name: "week|month|year|all", Type: ArgWord, Optional: true},
+		},
+		Description: "rank the members by the coins claimed, the events claimed or the longest claim streak",
+		Handlerfunc: (*Bot).handleCommandLeaderboard,
+	},
+	{
+		Command: "reminders",
+		Args: []Arg{
+			{Name: "on|off", Type: ArgWord, Optional: true},
+		},
+		Description: "show or choose whether to get private reminders to claim",
+		Handlerfunc: (*Bot).handleCommandReminders,
+	},
+	{
+		Command:     "referral",
+		Description: "get your personal link to invite others",
+		Handlerfunc: (*Bot).handleCommandReferral,
+	},
+	{
+		Admin:       true,
+		Command:     "referrals",
+		Description: "list the members who invited the most users",
+		Handlerfunc: (*Bot).handleCommandReferrals,
+	},
+	{
+		Command:     "myaddress",
+		Description: "show your saved payout address",
+		Handlerfunc: (*Bot).handleCommandMyAddress,
+	},
+	{
+		Admin:   true,
+		Command: "requireaddress",
+		Args: []Arg{
+			{Name: "on|off", Type: ArgWord, Optional: true},
+		},
+		Description: "only let users with a saved address participate in events",
+		Handlerfunc: (*Bot).handleCommandRequireAddress,
+	},
+	{
+		Admin:   true,
+		Command: "distribution",
+		Args: []Arg{
+			{Name: "equal|activity|referrals", Type: ArgWord, Optional: true},
+			{Name: "max_share", Type: ArgInt, Optional: true},
+		},
+		Description: "split the coins of events equally, by recent activity or by recent referrals, capping the share of one participant",
+		Handlerfunc: (*Bot).handleCommandDistribution,
+	},
+	{
+		Admin:   true,
+		Command: "topactive",
+		Args: []Arg{
+			{Name: "days", Type: ArgInt, Optional: true},
+		},
+		Description: "list the most active members of the group",
+		Handlerfunc: (*Bot).handleCommandTopActive,
+	},
+	{
+		Command:     "listevent",
+		Description: "list the current event (admins can also see surprise events)",
+		Handlerfunc: (*Bot).handleCommandListEvent,
+	},
+	{
+		Admin:   true,
+		Command: "adduser",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser, Variadic: true},
+		},
+		Description: "force add users to eligible list",
+		Handlerfunc: (*Bot).handleCommandAddUser,
+	},
+	{
+		Admin:   true,
+		Command: "makeadmin",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+		},
+		Description: "make a user an admin",
+		Handlerfunc: (*Bot).handleCommandMakeAdmin,
+	},
+	{
+		Admin:   true,
+		Command: "removeadmin",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+		},
+		Description: "remove user from admin position",
+		Handlerfunc: (*Bot).handleCommandRemoveAdmin,
+	},
+	{
+		Admin:   true,
+		Command: "banuser",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+			{Name: "duration", Type: ArgDuration, Optional: true},
+			{Name: "reason", Type: ArgText, Optional: true},
+		},
+		Description: "blacklist user from eligible list, for a duration if given",
+		Handlerfunc: (*Bot).handleCommandBanUser,
+		Confirm:     true,
+	},
+	{
+		Admin:   true,
+		Command: "unbanuser",
+		Args: []Arg{
+			{Name: "user", Type: ArgUser},
+		},
+		Description: "remove user from blacklist",
+		Handlerfunc: (*Bot).handleCommandUnBanUser,
+	},
+	{
+		Admin:   true,
+		Command: "announce",
+		Args: []Arg{
+			{Name: "message", Type: ArgText},
+		},
+		Description: "send announcement",
+		Handlerfunc: (*Bot).handleCommandAnnounce,
+	},
+	{
+		Admin:       true,
+		Command:     "announceevent",
+		Description: "force send current scheduled or ongoing event announcement",
+		Handlerfunc: (*Bot).handleCommandAnnounceEvent,
+	},
+	{
+		Admin:       true,
+		Command:     "usercount",
+		Description: "return number of users",
+		Handlerfunc: (*Bot).handleCommandUserCount,
+	},
+	{
+		Admin:       true,
+		Command:     "syncusers",
+		Description: "check the membership of all users with telegram",
+		Handlerfunc: (*Bot).handleCommandSyncUsers,
+	},
+	{
+		Admin:       true,
+		Command:     "users",
+		Args:        listOptions,
+		Description: "return all users in list, options: prefix=, enlisted, admins, sort=name|id",
+		Handlerfunc: func(bot *Bot, ctx *Context, args Args) error {
 			banned := false
-			return bot.handleCommandUsersParsed(ctx, banned)
+			return bot.handleCommandUsersParsed(ctx, banned, args)
 		},
 	},
-	Command{
-		true,
-		"bannedusers",
-		func(bot *Bot, ctx *Context, command, args string) error {
+	{
+		Admin:       true,
+		Command:     "bannedusers",
+		Args:        listOptions,
+		Description: "return all users in banned list, options: prefix=, enlisted, admins, sort=name|id",
+		Handlerfunc: func(bot *Bot, ctx *Context, args Args) error {
 			banned := true
-			return bot.handleCommandUsersParsed(ctx, banned)
+			return bot.handleCommandUsersParsed(ctx, banned, args)
+		},
+	},
+	{
+		Admin:   true,
+		Command: "listwinners",
+		Args: []Arg{
+			{Name: "event", Type: ArgEvent},
+			{Name: "options", Type: ArgWord, Optional: true, Variadic: true},
+		},
+		Description: "return a list of event winners, options: prefix=, enlisted, admins, sort=coins|name|id",
+		Handlerfunc: (*Bot).handleCommandListWinners,
+	},
+	{
+		Admin:   true,
+		Command: "events",
+		Args: []Arg{
+			{Name: "n", Type: ArgInt, Optional: true},
+		},
+		Description: "list the last finished events with their claim rates",
+		Handlerfunc: (*Bot).handleCommandEvents,
+	},
+	{
+		Admin:   true,
+		Command: "eventstats",
+		Args: []Arg{
+			{Name: "event", Type: ArgEvent},
+		},
+		Description: "show the claim statistics of an event",
+		Handlerfunc: (*Bot).handleCommandEventStats,
+	},
+	{
+		Admin:       true,
+		Command:     "suspicious",
+		Description: "list the users with held payouts claiming to the same or linked addresses",
+		Handlerfunc: (*Bot).handleCommandSuspicious,
+	},
+	{
+		Admin:   true,
+		Command: "paid",
+		Args: []Arg{
+			{Name: "event", Type: ArgEvent},
+			{Name: "txid", Type: ArgWord},
+			{Name: "users", Type: ArgUser, Optional: true, Variadic: true},
+		},
+		Description: "record the transaction which paid the claims in an event, of the users or of everyone",
+		Handlerfunc: (*Bot).handleCommandPaid,
+	},
+	{
+		Admin:   true,
+		Command: "exportwinners",
+		Args: []Arg{
+			{Name: "event", Type: ArgEvent},
+			{Name: "format", Type: ArgWord, Optional: true},
+		},
+		Description: "privately send the participants of an event as a csv or json document",
+		Handlerfunc: (*Bot).handleCommandExportWinners,
+	},
+	{
+		Admin:   true,
+		Command: "exportusers",
+		Args: []Arg{
+			{Name: "format", Type: ArgWord, Optional: true},
 		},
+		Description: "privately send all users as a csv or json document",
+		Handlerfunc: (*Bot).handleCommandExportUsers,
 	},
-	Command{
-		true,
-		"listwinners",
-		(*Bot).handleCommandListWinners,
+	{
+		Admin:       true,
+		Command:     "templates",
+		Description: "list announcement templates and where they come from",
+		Handlerfunc: (*Bot).handleCommandTemplates,
+	},
+	{
+		Admin:   true,
+		Command: "settemplate",
+		Args: []Arg{
+			{Name: "stage", Type: ArgWord},
+			{Name: "template", Type: ArgText},
+		},
+		Description: "change the announcement template of an event stage",
+		Handlerfunc: (*Bot).handleCommandSetTemplate,
+	},
+	{
+		Admin:   true,
+		Command: "resettemplate",
+		Args: []Arg{
+			{Name: "stage", Type: ArgWord},
+		},
+		Description: "restore the configured announcement template of an event stage",
+		Handlerfunc: (*Bot).handleCommandResetTemplate,
+		Confirm:     true,
+	},
+	{
+		Admin:   true,
+		Command: "previewtemplate",
+		Args: []Arg{
+			{Name: "stage", Type: ArgWord},
+			{Name: "template", Type: ArgText, Optional: true},
+		},
+		Description: "privately render an announcement with the current event or a sample one",
+		Handlerfunc: (*Bot).handleCommandPreviewTemplate,
 	},
 }
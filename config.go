@@ -13,9 +13,18 @@ type WalletConfig struct {
 }
 
 type Config struct {
-	Debug         bool           `json:"debug"`
-	Token         string         `json:"token"`
-	ChatID        int64          `json:"chat_id"`
-	Database      DatabaseConfig `json:"database"`
-	AnnounceEvery Duration       `json:"announce_every"`
+	Debug          bool              `json:"debug"`
+	Token          string            `json:"token"`
+	ChatID         int64             `json:"chat_id"`
+	Database       DatabaseConfig    `json:"database"`
+	Wallet         WalletConfig      `json:"wallet"` // the node is asked for linked addresses
+	AnnounceEvery  Duration          `json:"announce_every"`
+	PinEvents      bool              `json:"pin_events"`      // edit one pinned message instead of posting announcements
+	Language       string            `json:"language"`        // of the group, unless chosen with /chatlanguage
+	Templates      map[string]string `json:"templates"`       // announcement templates by event stage
+	CaptchaTimeout Duration          `json:"captcha_timeout"` // for new members to pass the challenge
+	SyncInterval   Duration          `json:"sync_interval"`   // between membership checks with telegram
+	MirrorAdmins   bool              `json:"mirror_admins"`   // make the group administrators the bot admins
+	Admins         []int             `json:"admins"`          // user ids of the admins who cannot be removed
+	ClaimReminders []string          `json:"claim_reminders"` // "50%" of the duration or "10m" before the end
 }
@@ -3,7 +3,9 @@ package skyaway
 import (
 	"errors"
 	"fmt"
-	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"database/sql"
@@ -17,6 +19,9 @@ type DB struct {
 
 var NotParticipating = errors.New("the user is not participating in the event")
 var AlreadyClaimed = errors.New("the user has already claimed coins in the event")
+var AlreadyAnswered = errors.New("the user has already answered the quiz")
+var QuizClosed = errors.New("the quiz does not accept answers anymore")
+var AlreadyOptedIn = errors.New("the user has already opted in to the event")
 
 func (db *DB) ScheduleEvent(coins int, start time.Time, duration Duration, surprise bool) error {
 	_, err := db.Exec(db.Rebind(`
@@ -28,7 +33,25 @@ func (db *DB) ScheduleEvent(coins int, start time.Time, duration Duration, surpr
 	return err
 }
 
-func (db *DB) StartNewEvent(coins int, duration Duration) error {
+// Changes the event which has not started yet.
+func (db *DB) UpdateEvent(e *Event, coins int, start time.Time, duration Duration, surprise bool) error {
+	scheduledAt := NewNullTime(start)
+	res, err := db.Exec(db.Rebind(`
+		update event set coins = ?, scheduled_at = ?, duration = ?, surprise = ?
+		where id = ? and started_at is null and ended_at is null`),
+		coins, scheduledAt, duration, surprise, e.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return errors.New("the event has already started or ended")
+	}
+	e.Coins, e.ScheduledAt, e.Duration, e.Surprise = coins, scheduledAt, duration, surprise
+	return nil
+}
+
+func (db *DB) StartNewEvent(coins int, duration Duration, elig Eligibility) error {
 	tx, err := db.Beginx()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %v", err)
@@ -50,7 +73,11 @@ func (db *DB) StartNewEvent(coins int, duration Duration) error {
 		return fmt.Errorf("event inserted, but could not be found immediatly after: %v", err)
 	}
 
-	if err := event.addParticipants(tx); err != nil {
+	if err := event.takeLeftovers(tx); err != nil {
+		return err
+	}
+
+	if err := event.addParticipants(tx, elig); err != nil {
 		return fmt.Errorf("failed to add participants: %v", err)
 	}
 
@@ -61,42 +88,81 @@ func (db *DB) StartNewEvent(coins int, duration Duration) error {
 	return nil
 }
 
-func (e *Event) addParticipants(tx *sqlx.Tx) error {
+func (e *Event) addParticipants(tx *sqlx.Tx, elig Eligibility) error {
+	query := `
+		SELECT u.id, u.username, coalesce(sum(a.messages), 0) AS messages, (
+			SELECT count(*) FROM referral r
+			WHERE r.referrer_id = u.id AND r.recorded_at > now() - cast(? AS INT) * interval '1 day'
+		) AS referrals
+		FROM botuser u
+		LEFT JOIN activity a ON a.user_id = u.id AND a.day > current_date - cast(? AS INT)
+		WHERE NOT u.banned AND u.enlisted`
+	params := []interface{}{activityDays, activityDays}
+	if elig.RequireAddress {
+		query += " AND u.address <> ''"
+	}
+	if e.Registration.Valid {
+		// only those who opted in
+		query += " AND u.id IN (SELECT user_id FROM participant WHERE event_id = ?)"
+		params = append(params, e.ID)
+	}
+	query += " GROUP BY u.id, u.username"
+
 	var users []TempUser
-	err := tx.Select(&users, "SELECT id, username FROM botuser WHERE NOT banned AND enlisted")
+	err := tx.Select(&users, tx.Rebind(query), params...)
 	if err != nil {
 		return fmt.Errorf("failed to select eligible users for coin distribution: %v", err)
 	}
 
-	if len(users) == 0 {
-		return nil
-	}
+	var shares []int
+	if len(users) > 0 {
+		weights := make([]int, len(users))
+		active := false
+		for i, user := range users {
+			weights[i] = user.Messages
+			if elig.Distribution == DistributionReferrals {
+				weights[i] = user.Referrals
+			}
+			active = active || weights[i] > 0
+		}
 
-	coinsPerUser := e.Coins / len(users)
-	volatility := 0
-	if e.Coins%len(users) != 0 {
-		volatility = 1
+		weighted := elig.Distribution == DistributionActivity || elig.Distribution == DistributionReferrals
+		if weighted && active {
+			shares = activityShares(e.Coins, weights, elig.MaxShare)
+		} else {
+			shares = equalShares(e.Coins, len(users))
+		}
 	}
 
-	for _, user := range users {
-		coins := coinsPerUser + rand.Intn(volatility+1)
+	for i, user := range users {
+		coins := shares[i]
+		if coins == 0 {
+			continue
+		}
 		_, err := tx.Exec(tx.Rebind(`
 			insert into participant (
 				event_id, user_id, username, coins
-			) values (?, ?, ?, ?)`),
+			) values (?, ?, ?, ?)
+			on conflict (event_id, user_id) do update set coins = excluded.coins`),
 			e.ID, user.ID, user.UserName, coins,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to add user to event participants: %v", err)
 		}
 	}
+
+	// the registered users who are not eligible anymore or got nothing
+	_, err = tx.Exec(tx.Rebind("delete from participant where event_id = ? and coins = 0"), e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to remove the participants without coins: %v", err)
+	}
 	return nil
 }
 
 func (db *DB) CoinsClaimed(e *Event) (int, error) {
 	var coins int
 	err := db.Get(&coins, db.Rebind(`
-		select sum(coins)
+		select coalesce(sum(coins), 0)
 		from participant
 		where event_id = ? and claimed_at is not null`),
 		e.ID,
@@ -129,7 +195,155 @@ func (db *DB) ClaimersLeft(e *Event) (int, error) {
 	return claimers, nil
 }
 
-func (db *DB) StartEvent(e *Event) error {
+func (db *DB) ParticipantCount(e *Event) (int, error) {
+	var count int
+	err := db.Get(&count, db.Rebind(`
+		select count(user_id)
+		from participant
+		where event_id = ?`),
+		e.ID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count participants: %v", err)
+	}
+	return count, nil
+}
+
+// Totals of a finished event.
+type EventSummary struct {
+	ID           int      `db:"id"`
+	Coins        int      `db:"coins"`
+	Duration     Duration `db:"duration"`
+	StartedAt    NullTime `db:"started_at"`
+	EndedAt      NullTime `db:"ended_at"`
+	Participants int      `db:"participants"`
+	Claimers     int      `db:"claimers"`
+	Claimed      int      `db:"claimed"` // coins
+}
+
+// The percentage of participants who claimed their coins.
+func (s *EventSummary) ClaimRate() int {
+	if s.Participants == 0 {
+		return 0
+	}
+	return s.Claimers * 100 / s.Participants
+}
+
+// Returns the totals of the last `n` finished events, newest first.
+func (db *DB) GetEventSummaries(n int) ([]EventSummary, error) {
+	var summaries []EventSummary
+	err := db.Select(&summaries, db.Rebind(`
+		select
+			e.id, e.coins, e.duration, e.started_at, e.ended_at,
+			count(p.user_id) as participants,
+			count(p.claimed_at) as claimers,
+			coalesce(sum(case when p.claimed_at is not null then p.coins end), 0) as claimed
+		from event e
+		left join participant p on p.event_id = e.id
+		where e.started_at is not null and e.ended_at is not null
+		group by e.id
+		order by e.id desc
+		limit ?`),
+		n,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event summaries: %v", err)
+	}
+	return summaries, nil
+}
+
+func (db *DB) GetEventSummary(e *Event) (*EventSummary, error) {
+	var summary EventSummary
+	err := db.Get(&summary, db.Rebind(`
+		select
+			e.id, e.coins, e.duration, e.started_at, e.ended_at,
+			count(p.user_id) as participants,
+			count(p.claimed_at) as claimers,
+			coalesce(sum(case when p.claimed_at is not null then p.coins end), 0) as claimed
+		from event e
+		left join participant p on p.event_id = e.id
+		where e.id = ?
+		group by e.id`),
+		e.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event summary: %v", err)
+	}
+	return &summary, nil
+}
+
+// Returns the time from the start of the event to the first claim, or false
+// if nobody has claimed yet.
+func (db *DB) TimeToFirstClaim(e *Event) (time.Duration, bool, error) {
+	var seconds sql.NullFloat64
+	err := db.Get(&seconds, db.Rebind(`
+		select extract(epoch from min(p.claimed_at) - e.started_at)
+		from event e
+		join participant p on p.event_id = e.id
+		where e.id = ?
+		group by e.started_at`),
+		e.ID,
+	)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get the first claim: %v", err)
+	}
+	if !seconds.Valid {
+		return 0, false, nil
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), true, nil
+}
+
+// Counts the claims of the event in the consecutive intervals of `width`
+// since the start. Intervals without claims are zero.
+func (db *DB) ClaimHistogram(e *Event, width time.Duration) ([]int, error) {
+	var rows []struct {
+		Bucket int `db:"bucket"`
+		Claims int `db:"claims"`
+	}
+	err := db.Select(&rows, db.Rebind(`
+		select
+			floor(extract(epoch from p.claimed_at - e.started_at) / ?)::int as bucket,
+			count(*) as claims
+		from participant p
+		join event e on e.id = p.event_id
+		where e.id = ? and p.claimed_at is not null
+		group by bucket
+		order by bucket`),
+		width.Seconds(), e.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the claim histogram: %v", err)
+	}
+
+	var histogram []int
+	for _, row := range rows {
+		if row.Bucket < 0 {
+			row.Bucket = 0
+		}
+		for len(histogram) <= row.Bucket {
+			histogram = append(histogram, 0)
+		}
+		histogram[row.Bucket] += row.Claims
+	}
+	return histogram, nil
+}
+
+func (db *DB) SetEventMessage(e *Event, messageID int) error {
+	id := sql.NullInt64{Int64: int64(messageID), Valid: true}
+	_, err := db.Exec(
+		db.Rebind("update event set message_id = ? where id = ?"),
+		id, e.ID,
+	)
+	if err == nil {
+		e.MessageID = id
+	}
+	return err
+}
+
+func (db *DB) StartEvent(e *Event, elig Eligibility) error {
 	if e.StartedAt.Valid {
 		return errors.New("already started")
 	}
@@ -149,8 +363,20 @@ func (db *DB) StartEvent(e *Event) error {
 		return fmt.Errorf("failed to update event status: %v", err)
 	}
 
-	if err := e.addParticipants(tx); err != nil {
-		return fmt.Errorf("failed to add participants: %v", err)
+	if err := e.takeLeftovers(tx); err != nil {
+		return err
+	}
+
+	// the participants of a quiz are those who answer correctly
+	var quiz bool
+	err = tx.Get(&quiz, tx.Rebind("select exists(select 1 from quiz where event_id = ?)"), e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check for a quiz: %v", err)
+	}
+	if !quiz {
+		if err := e.addParticipants(tx, elig); err != nil {
+			return fmt.Errorf("failed to add participants: %v", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -176,6 +402,115 @@ func (db *DB) EndEvent(e *Event) error {
 	return err
 }
 
+func (db *DB) SetRollover(e *Event, rollover string) error {
+	_, err := db.Exec(db.Rebind("update event set rollover = ? where id = ?"), rollover, e.ID)
+	if err == nil {
+		e.Rollover = rollover
+	}
+	return err
+}
+
+// Adds the coins left over from the ended events which roll over to the
+// next one to the pool of the event.
+func (e *Event) takeLeftovers(tx *sqlx.Tx) error {
+	var coins int
+	err := tx.Get(&coins, tx.Rebind(`
+		select coalesce(sum(leftover), 0) from event
+		where rollover = ? and leftover > 0 and rolled_into is null and ended_at is not null`),
+		RolloverNext,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to count the leftovers: %v", err)
+	}
+	if coins == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(tx.Rebind(`
+		update event set rolled_into = ?
+		where rollover = ? and leftover > 0 and rolled_into is null and ended_at is not null`),
+		e.ID, RolloverNext,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to take the leftovers: %v", err)
+	}
+	_, err = tx.Exec(tx.Rebind("update event set coins = coins + ? where id = ?"), coins, e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to add the leftovers: %v", err)
+	}
+	e.Coins += coins
+	return nil
+}
+
+// Records how many coins of the ended event were not claimed.
+func (db *DB) SetLeftover(e *Event, coins int) error {
+	leftover := sql.NullInt64{Int64: int64(coins), Valid: true}
+	_, err := db.Exec(db.Rebind("update event set leftover = ? where id = ?"), leftover, e.ID)
+	if err == nil {
+		e.Leftover = leftover
+	}
+	return err
+}
+
+// Starts an event with the leftover of the ended one, split among those who
+// claimed in it. The payouts held for a review and the banned users are left
+// out. Returns nil if nobody is left to split among.
+func (db *DB) StartSecondRound(prev *Event) (*Event, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var claimers []TempUser
+	err = tx.Select(&claimers, tx.Rebind(`
+		select u.id, u.username
+		from participant p join botuser u on u.id = p.user_id
+		where p.event_id = ? and p.claimed_at is not null and not p.held and not u.banned
+		order by p.claimed_at, u.id`),
+		prev.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select the claimers: %v", err)
+	}
+	if len(claimers) == 0 || !prev.Leftover.Valid || prev.Leftover.Int64 <= 0 {
+		return nil, nil
+	}
+
+	var event Event
+	err = tx.Get(&event, tx.Rebind(`
+		insert into event (
+			coins, duration, started_at, surprise
+		) values (?, ?, ?, ?)
+		returning *`),
+		prev.Leftover.Int64, prev.Duration, NewNullTime(time.Now()), prev.Surprise,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert event: %v", err)
+	}
+
+	// the earliest claimers get the extra coins
+	for i, coins := range exactShares(event.Coins, len(claimers)) {
+		if coins == 0 {
+			continue
+		}
+		if err := addParticipant(tx, event.ID, claimers[i].ID, coins); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(tx.Rebind("update event set rolled_into = ? where id = ?"), event.ID, prev.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link the second round: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit the event: %v", err)
+	}
+	prev.RolledInto = sql.NullInt64{Int64: int64(event.ID), Valid: true}
+	return &event, nil
+}
+
 func (db *DB) GetCurrentEvent() *Event {
 	var event Event
 
@@ -187,7 +522,6 @@ func (db *DB) GetCurrentEvent() *Event {
 
 	if err != nil {
 		panic(err)
-		return nil
 	}
 
 	return &event
@@ -204,15 +538,47 @@ func (db *DB) GetLastEvent() *Event {
 
 	if err != nil {
 		panic(err)
+	}
+
+	return &event
+}
+
+func (db *DB) GetEvent(id int) *Event {
+	var event Event
+
+	err := db.Get(&event, db.Rebind("SELECT * FROM event WHERE id = ?"), id)
+
+	if err != nil {
 		return nil
 	}
 
 	return &event
 }
 
+// Finds the event by id, or the "last" or the "current" one.
+func (db *DB) FindEvent(text string) (*Event, error) {
+	var event *Event
+	switch text {
+	case "last":
+		event = db.GetLastEvent()
+	case "current":
+		event = db.GetCurrentEvent()
+	default:
+		id, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("expected an event id, 'last' or 'current': %s", text)
+		}
+		event = db.GetEvent(id)
+	}
+	if event == nil {
+		return nil, fmt.Errorf("no such event: %s", text)
+	}
+	return event, nil
+}
+
 func NewDB(config *DatabaseConfig) (*DB, error) {
 	if config == nil {
-		errors.New("config should not be nil in NewDB()")
+		return nil, errors.New("config should not be nil in NewDB()")
 	}
 	db, err := sqlx.Open(config.Driver, config.Source)
 	if err != nil {
@@ -252,28 +618,129 @@ func (db *DB) GetUserByName(name string) *User {
 	return &user
 }
 
+// Finds the user by the current username, the id or a former username. The
+// former name is set on the user found by it.
 func (db *DB) GetUserByNameOrId(identifier string) *User {
-	var user User
-	var err error
+	// usernames cannot be numbers
+	if id, err := strconv.Atoi(identifier); err == nil {
+		return db.GetUser(id)
+	}
 
-	err = db.Get(&user, db.Rebind("select * from botuser where username=? or id=?"), identifier, identifier)
+	if user := db.GetUserByName(identifier); user != nil {
+		return user
+	}
+	return db.GetUserByAlias(identifier)
+}
 
-	if err == sql.ErrNoRows {
+// Finds the user who had the username before, most recently. The former
+// name is set on the user returned.
+func (db *DB) GetUserByAlias(name string) *User {
+	var id int
+	err := db.Get(&id, db.Rebind(`
+		select user_id from user_alias where username = ?
+		order by changed_at desc limit 1`),
+		name,
+	)
+	if err != nil {
 		return nil
 	}
 
+	user := db.GetUser(id)
+	if user != nil && user.UserName != name {
+		user.FormerName = name
+	}
+	return user
+}
+
+// Changes the names of the user keeping the old ones in the aliases, and
+// updates the names in the participant lists.
+func (db *DB) RenameUser(u *User, username, firstName, lastName string) error {
+	tx, err := db.Beginx()
 	if err != nil {
-		return nil
+		return fmt.Errorf("failed to begin transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	user.exists = true
-	return &user
+	_, err = tx.Exec(tx.Rebind(`
+		insert into user_alias (user_id, username, first_name, last_name)
+		values (?, ?, ?, ?)`),
+		u.ID, u.UserName, u.FirstName, u.LastName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to keep the former name: %v", err)
+	}
+	_, err = tx.Exec(tx.Rebind(`
+		update botuser set username = ?, first_name = ?, last_name = ?
+		where id = ?`),
+		username, firstName, lastName, u.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update the name: %v", err)
+	}
+	_, err = tx.Exec(tx.Rebind("update participant set username = ? where user_id = ?"), username, u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update the name of the participant: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the name: %v", err)
+	}
+	u.UserName = username
+	u.FirstName = firstName
+	u.LastName = lastName
+	return nil
+}
+
+// Filters and sorting of the user lists.
+type ListFilter struct {
+	Prefix   string // of the username, case insensitive
+	Enlisted bool   // enlisted users only
+	Admins   bool   // admins only
+	Sort     string // a key of the orders of the list
+}
+
+// Orders of the user list by sort key.
+var userOrders = map[string]string{
+	"name": "u.username",
+	"id":   "u.id",
+}
+
+// Orders of the winner list by sort key.
+var winnerOrders = map[string]string{
+	"name":  "p.username",
+	"id":    "p.user_id",
+	"coins": "p.coins desc",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Appends the conditions and the order of the filter to the query, which
+// should select from botuser aliased as "u".
+func (f ListFilter) apply(query string, params []interface{}, orders map[string]string) (string, []interface{}) {
+	if f.Prefix != "" {
+		query += " and lower(u.username) like lower(?)"
+		params = append(params, likeEscaper.Replace(f.Prefix)+"%")
+	}
+	if f.Enlisted {
+		query += " and u.enlisted"
+	}
+	if f.Admins {
+		query += " and u.admin"
+	}
+	if order, ok := orders[f.Sort]; ok {
+		query += " order by " + order
+	}
+	return query, params
 }
 
-func (db *DB) GetUsers(banned bool) ([]User, error) {
+func (db *DB) GetUsers(banned bool, filter ListFilter) ([]User, error) {
 	var users []User
 
-	err := db.Select(&users, db.Rebind("select * from botuser where banned = ? order by username"), banned)
+	query, params := filter.apply(
+		"select u.* from botuser u where u.banned = ?",
+		[]interface{}{banned}, userOrders,
+	)
+	err := db.Select(&users, db.Rebind(query), params...)
 	if err != nil {
 		return nil, err
 	}
@@ -281,28 +748,58 @@ func (db *DB) GetUsers(banned bool) ([]User, error) {
 	return users, nil
 }
 
-func (db *DB) GetWinners(eventID int) ([]Participant, error) {
+// Returns all known users, banned or not, ready to be updated.
+func (db *DB) GetAllUsers() ([]User, error) {
+	var users []User
+	err := db.Select(&users, "select * from botuser order by id")
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].exists = true
+	}
+	return users, nil
+}
+
+func (db *DB) GetWinners(eventID int, filter ListFilter) ([]Participant, error) {
 	var winners []Participant
 
-	err := db.Select(&winners, db.Rebind("Select * from participant where event_id=?"), eventID)
+	query, params := filter.apply(`
+		select p.* from participant p
+		join botuser u on u.id = p.user_id
+		where p.event_id = ?`,
+		[]interface{}{eventID}, winnerOrders,
+	)
+	err := db.Select(&winners, db.Rebind(query), params...)
 
 	if err != nil {
-		return []Participant{}, nil
+		return nil, err
 	}
 
 	return winners, nil
 }
 
-func (db *DB) ClaimCoins(user *User, event *Event) error {
-	_, err := db.Exec(db.Rebind(`
+// Marks the coins of the user as claimed to the address. Returns
+// `AlreadyClaimed` if they have been claimed before.
+func (db *DB) ClaimCoins(user *User, event *Event, address string) error {
+	result, err := db.Exec(db.Rebind(`
 		update participant
-		set claimed_at = now()
+		set claimed_at = now(), address = ?
 		where
 			user_id = ?
-			and event_id = ?`),
-		user.ID, event.ID,
+			and event_id = ?
+			and claimed_at is null`),
+		address, user.ID, event.ID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return AlreadyClaimed
+	}
+	return nil
 }
 
 func (db *DB) GetCoinsToClaim(user *User, event *Event) (int, error) {
@@ -332,47 +829,274 @@ func (db *DB) GetCoinsToClaim(user *User, event *Event) (int, error) {
 	return coins, nil
 }
 
-func (db *DB) GetUserCount(banned bool) (int, error) {
-	var count int
+// Returns the participation of the user in the event, or nil if the user
+// does not participate.
+func (db *DB) GetParticipant(user *User, event *Event) (*Participant, error) {
+	var p Participant
+	err := db.Get(&p, db.Rebind(`
+		select * from participant
+		where user_id = ? and event_id = ?`),
+		user.ID, event.ID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participant: %v", err)
+	}
+	return &p, nil
+}
 
-	err := db.Get(&count, db.Rebind("select count(*) from botuser where banned = ?"), banned)
+// Returns the participations of the user in the finished events, newest
+// first.
+func (db *DB) GetParticipations(user *User) ([]Participation, error) {
+	var participations []Participation
+	err := db.Select(&participations, db.Rebind(`
+		select p.*, e.started_at
+		from participant p
+		join event e on e.id = p.event_id
+		where p.user_id = ? and e.ended_at is not null
+		order by e.id desc`),
+		user.ID,
+	)
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("failed to get participations: %v", err)
 	}
+	return participations, nil
+}
 
-	return count, nil
+// Returns the other users who have ever claimed coins to the address.
+func (db *DB) GetAddressClaimers(address string, except *User) ([]int, error) {
+	var ids []int
+	err := db.Select(&ids, db.Rebind(`
+		select distinct user_id from participant
+		where address = ? and user_id <> ?`),
+		address, except.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get address claimers: %v", err)
+	}
+	return ids, nil
 }
 
-func (db *DB) PutUser(u *User) error {
-	if u.exists {
-		_, err := db.Exec(db.Rebind(`
-			update botuser
-				set username = ?,
-				first_name = ?,
-				last_name = ?,
-				banned = ?,
-				admin = ?
-			where id = ?`),
-			u.UserName,
-			u.FirstName,
-			u.LastName,
-			u.Banned,
-			u.Admin,
-			u.ID,
-		)
-		return err
-	} else {
-		_, err := db.Exec(db.Rebind(`
-			insert into botuser (
-				id, username, first_name, last_name,
-				banned, admin
-			) values (?, ?, ?, ?, ?, ?)`),
-			u.ID,
-			u.UserName,
-			u.FirstName,
+// Remembers that the addresses probably belong to the same person.
+func (db *DB) AddAddressLink(a, b string) error {
+	if b < a {
+		a, b = b, a
+	}
+	_, err := db.Exec(db.Rebind(`
+		insert into address_link (a, b) values (?, ?)
+		on conflict do nothing`),
+		a, b,
+	)
+	return err
+}
+
+// Holds the payouts of the users in the event for an admin review.
+func (db *DB) HoldClaims(event *Event, userIDs []int) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range userIDs {
+		_, err := tx.Exec(tx.Rebind(`
+			update participant set held = true
+			where event_id = ? and user_id = ? and txid = ''`),
+			event.ID, id,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to hold the claim: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Records the transaction which paid the claims in the event. Only the
+// claimed payouts which are not held or paid yet are recorded, of the given
+// users or of everyone if none are given. Returns how many were recorded.
+func (db *DB) SetPaid(event *Event, txid string, userIDs []int) (int, error) {
+	query := `
+		update participant set txid = ?
+		where event_id = ? and claimed_at is not null and not held and txid = ''`
+	params := []interface{}{txid, event.ID}
+	if len(userIDs) > 0 {
+		var marks []string
+		for _, id := range userIDs {
+			marks = append(marks, "?")
+			params = append(params, id)
+		}
+		query += " and user_id in (" + strings.Join(marks, ", ") + ")"
+	}
+
+	res, err := db.Exec(db.Rebind(query), params...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record the payout: %v", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// Releases the held payouts of the user.
+func (db *DB) ReleaseClaims(userID int) error {
+	_, err := db.Exec(db.Rebind("update participant set held = false where user_id = ?"), userID)
+	return err
+}
+
+// Returns the groups of users who claimed to the same addresses or to the
+// linked ones. Only groups of several users are returned, and only while
+// some of them who are not banned have held payouts waiting for a review.
+func (db *DB) GetSuspiciousClusters() ([][]int, error) {
+	var claims []struct {
+		UserID  int    `db:"user_id"`
+		Address string `db:"address"`
+	}
+	err := db.Select(&claims, "select distinct user_id, address from participant where address <> ''")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claim addresses: %v", err)
+	}
+	var links []struct {
+		A string `db:"a"`
+		B string `db:"b"`
+	}
+	if err := db.Select(&links, "select a, b from address_link"); err != nil {
+		return nil, fmt.Errorf("failed to get address links: %v", err)
+	}
+	var held []int
+	err = db.Select(&held, `
+		select distinct p.user_id from participant p join botuser u on u.id = p.user_id
+		where p.held and not u.banned`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held claims: %v", err)
+	}
+	pending := make(map[int]bool)
+	for _, id := range held {
+		pending[id] = true
+	}
+
+	// union-find over the addresses
+	parent := make(map[string]string)
+	var root func(string) string
+	root = func(a string) string {
+		p, ok := parent[a]
+		if !ok || p == a {
+			return a
+		}
+		r := root(p)
+		parent[a] = r
+		return r
+	}
+	for _, link := range links {
+		if ra, rb := root(link.A), root(link.B); ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	users := make(map[string]map[int]bool)
+	var roots []string
+	for _, claim := range claims {
+		r := root(claim.Address)
+		if users[r] == nil {
+			users[r] = make(map[int]bool)
+			roots = append(roots, r)
+		}
+		users[r][claim.UserID] = true
+	}
+
+	var clusters [][]int
+	for _, r := range roots {
+		if len(users[r]) < 2 {
+			continue
+		}
+		var cluster []int
+		reviewed := true
+		for id := range users[r] {
+			cluster = append(cluster, id)
+			reviewed = reviewed && !pending[id]
+		}
+		if reviewed {
+			continue
+		}
+		sort.Ints(cluster)
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// Returns the banned users whose bans have expired.
+func (db *DB) GetExpiredBans() ([]User, error) {
+	var users []User
+	err := db.Select(&users, "select * from botuser where banned and banned_until < now()")
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].exists = true
+	}
+	return users, nil
+}
+
+func (db *DB) GetUserCount(banned bool) (int, error) {
+	var count int
+
+	err := db.Get(&count, db.Rebind("select count(*) from botuser where banned = ?"), banned)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (db *DB) PutUser(u *User) error {
+	if u.exists {
+		_, err := db.Exec(db.Rebind(`
+			update botuser
+				set username = ?,
+				first_name = ?,
+				last_name = ?,
+				enlisted = ?,
+				banned = ?,
+				admin = ?,
+				language = ?,
+				verification = ?,
+				ban_reason = ?,
+				banned_by = ?,
+				banned_until = ?
+			where id = ?`),
+			u.UserName,
+			u.FirstName,
+			u.LastName,
+			u.Enlisted,
+			u.Banned,
+			u.Admin,
+			u.Language,
+			u.Verification,
+			u.BanReason,
+			u.BannedBy,
+			u.BannedUntil,
+			u.ID,
+		)
+		return err
+	} else {
+		_, err := db.Exec(db.Rebind(`
+			insert into botuser (
+				id, username, first_name, last_name,
+				enlisted, banned, admin, language, verification,
+				ban_reason, banned_by, banned_until
+			) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			u.ID,
+			u.UserName,
+			u.FirstName,
 			u.LastName,
+			u.Enlisted,
 			u.Banned,
 			u.Admin,
+			u.Language,
+			u.Verification,
+			u.BanReason,
+			u.BannedBy,
+			u.BannedUntil,
 		)
 		if err == nil {
 			u.exists = true
@@ -380,3 +1104,618 @@ func (db *DB) PutUser(u *User) error {
 		return err
 	}
 }
+
+// Returns the language chosen for the chat, or an empty string.
+func (db *DB) GetChatLanguage(chatID int64) string {
+	var lang string
+	err := db.Get(&lang, db.Rebind("select language from chat where id = ?"), chatID)
+	if err != nil {
+		return ""
+	}
+	return lang
+}
+
+// Saves the default payout address of the user and keeps the previous ones
+// in the history.
+func (db *DB) SetAddress(u *User, address string) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(tx.Rebind("update botuser set address = ? where id = ?"), address, u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update the address: %v", err)
+	}
+	_, err = tx.Exec(tx.Rebind(`
+		insert into address_change (user_id, address) values (?, ?)`),
+		u.ID, address,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to keep the address history: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the address: %v", err)
+	}
+	u.Address = address
+	return nil
+}
+
+func (db *DB) SetReminders(u *User, reminders bool) error {
+	_, err := db.Exec(db.Rebind("update botuser set reminders = ? where id = ?"), reminders, u.ID)
+	if err == nil {
+		u.Reminders = reminders
+	}
+	return err
+}
+
+// Marks whether the user cannot be messaged privately, because of never
+// having started a chat with the bot or having blocked it.
+func (db *DB) SetUnreachable(u *User, unreachable bool) error {
+	_, err := db.Exec(db.Rebind("update botuser set unreachable = ? where id = ?"), unreachable, u.ID)
+	if err == nil {
+		u.Unreachable = unreachable
+	}
+	return err
+}
+
+func (db *DB) SetReminded(e *Event, t time.Time) error {
+	remindedAt := NewNullTime(t)
+	_, err := db.Exec(db.Rebind("update event set reminded_at = ? where id = ?"), remindedAt, e.ID)
+	if err == nil {
+		e.RemindedAt = remindedAt
+	}
+	return err
+}
+
+// Returns the participants of the event who have not claimed yet and can be
+// reminded privately.
+func (db *DB) GetClaimersToRemind(e *Event) ([]User, error) {
+	var users []User
+	err := db.Select(&users, db.Rebind(`
+		select u.* from participant p join botuser u on u.id = p.user_id
+		where p.event_id = ? and p.claimed_at is null
+		and u.reminders and not u.unreachable and not u.banned`),
+		e.ID,
+	)
+	return users, err
+}
+
+func (db *DB) GetRequireAddress(chatID int64) bool {
+	var require bool
+	err := db.Get(&require, db.Rebind("select require_address from chat where id = ?"), chatID)
+	if err != nil {
+		return false
+	}
+	return require
+}
+
+func (db *DB) SetRequireAddress(chatID int64, require bool) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into chat (id, require_address) values (?, ?)
+		on conflict (id) do update set require_address = excluded.require_address`),
+		chatID, require,
+	)
+	return err
+}
+
+// Returns how the coins are split in the events of the chat, and the
+// maximum share of one participant in percent.
+func (db *DB) GetDistribution(chatID int64) (string, int) {
+	var settings struct {
+		Distribution string `db:"distribution"`
+		MaxShare     int    `db:"max_share"`
+	}
+	err := db.Get(&settings, db.Rebind("select distribution, max_share from chat where id = ?"), chatID)
+	if err != nil {
+		return DistributionEqual, 0
+	}
+	return settings.Distribution, settings.MaxShare
+}
+
+func (db *DB) SetDistribution(chatID int64, distribution string, maxShare int) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into chat (id, distribution, max_share) values (?, ?, ?)
+		on conflict (id) do update set
+			distribution = excluded.distribution,
+			max_share = excluded.max_share`),
+		chatID, distribution, maxShare,
+	)
+	return err
+}
+
+// Counts `n` more messages of the user on the day, given as YYYY-MM-DD.
+func (db *DB) AddMessages(userID int, day string, n int) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into activity (user_id, day, messages) values (?, ?, ?)
+		on conflict (user_id, day) do update set messages = activity.messages + excluded.messages`),
+		userID, day, n,
+	)
+	return err
+}
+
+// Returns the users who posted the most messages in the last days.
+func (db *DB) GetTopActive(days, limit int) ([]Activity, error) {
+	var top []Activity
+	err := db.Select(&top, db.Rebind(`
+		select a.user_id, u.username, sum(a.messages) as messages
+		from activity a
+		join botuser u on u.id = a.user_id
+		where a.day > current_date - cast(? as int)
+		group by a.user_id, u.username
+		order by messages desc, a.user_id
+		limit ?`),
+		days, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// Returns the challenge kinds for new members of the chat, and whether to
+// send them privately.
+func (db *DB) GetCaptcha(chatID int64) ([]string, bool) {
+	var settings struct {
+		Captcha   string `db:"captcha"`
+		CaptchaDM bool   `db:"captcha_dm"`
+	}
+	err := db.Get(&settings, db.Rebind("select captcha, captcha_dm from chat where id = ?"), chatID)
+	if err != nil {
+		return nil, false
+	}
+	return strings.Fields(settings.Captcha), settings.CaptchaDM
+}
+
+func (db *DB) SetCaptcha(chatID int64, kinds []string, dm bool) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into chat (id, captcha, captcha_dm) values (?, ?, ?)
+		on conflict (id) do update set captcha = excluded.captcha, captcha_dm = excluded.captcha_dm`),
+		chatID, strings.Join(kinds, " "), dm,
+	)
+	return err
+}
+
+func (db *DB) PutChallenge(c *Challenge) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into challenge (user_id, answer, chat_id, message_id, expires_at)
+		values (?, ?, ?, ?, ?)
+		on conflict (user_id) do update set
+			answer = excluded.answer,
+			chat_id = excluded.chat_id,
+			message_id = excluded.message_id,
+			expires_at = excluded.expires_at`),
+		c.UserID, c.Answer, c.ChatID, c.MessageID, c.ExpiresAt,
+	)
+	return err
+}
+
+// Records where the challenge has been sent once it is.
+func (db *DB) SetChallengeMessage(userID int, chatID int64, messageID int) error {
+	_, err := db.Exec(
+		db.Rebind("update challenge set chat_id = ?, message_id = ? where user_id = ?"),
+		chatID, messageID, userID,
+	)
+	return err
+}
+
+func (db *DB) GetChallenge(userID int) *Challenge {
+	var c Challenge
+	err := db.Get(&c, db.Rebind("select * from challenge where user_id = ?"), userID)
+	if err != nil {
+		return nil
+	}
+	return &c
+}
+
+func (db *DB) GetExpiredChallenges() ([]Challenge, error) {
+	var challenges []Challenge
+	err := db.Select(&challenges, "select * from challenge where expires_at < now()")
+	return challenges, err
+}
+
+func (db *DB) DeleteChallenge(userID int) error {
+	_, err := db.Exec(db.Rebind("delete from challenge where user_id = ?"), userID)
+	return err
+}
+
+func (db *DB) SetChatLanguage(chatID int64, lang string) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into chat (id, language) values (?, ?)
+		on conflict (id) do update set language = excluded.language`),
+		chatID, lang,
+	)
+	return err
+}
+
+// Returns the announcement templates stored in the database by stage.
+func (db *DB) GetTemplates() (map[string]string, error) {
+	var rows []struct {
+		Name string
+		Text string
+	}
+	if err := db.Select(&rows, "select name, text from template"); err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]string)
+	for _, row := range rows {
+		templates[row.Name] = row.Text
+	}
+	return templates, nil
+}
+
+func (db *DB) PutTemplate(name, text string) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into template (name, text) values (?, ?)
+		on conflict (name) do update set text = excluded.text`),
+		name, text,
+	)
+	return err
+}
+
+func (db *DB) DeleteTemplate(name string) error {
+	_, err := db.Exec(db.Rebind("delete from template where name = ?"), name)
+	return err
+}
+
+// Returns the quiz of the event, or nil if it is not a quiz event.
+func (db *DB) GetQuiz(eventID int) *Quiz {
+	var quiz Quiz
+	err := db.Get(&quiz, db.Rebind("select * from quiz where event_id = ?"), eventID)
+	if err != nil {
+		return nil
+	}
+	return &quiz
+}
+
+// Makes the event a quiz, or replaces its quiz.
+func (db *DB) PutQuiz(q *Quiz) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into quiz (
+			event_id, question, answers, options, match, winners, answer_for
+		) values (?, ?, ?, ?, ?, ?, ?)
+		on conflict (event_id) do update set
+			question = excluded.question,
+			answers = excluded.answers,
+			options = excluded.options,
+			match = excluded.match,
+			winners = excluded.winners,
+			answer_for = excluded.answer_for`),
+		q.EventID, q.Question, q.Answers, q.Options, q.Match, q.Winners, q.AnswerFor,
+	)
+	return err
+}
+
+func (db *DB) DeleteQuiz(eventID int) error {
+	_, err := db.Exec(db.Rebind("delete from quiz where event_id = ?"), eventID)
+	return err
+}
+
+func (db *DB) SetQuizMessage(q *Quiz, messageID int) error {
+	_, err := db.Exec(db.Rebind("update quiz set message_id = ? where event_id = ?"), messageID, q.EventID)
+	if err != nil {
+		return err
+	}
+	q.MessageID = sql.NullInt64{Int64: int64(messageID), Valid: true}
+	return nil
+}
+
+// Adds the user to the participants of the event with the coins.
+func addParticipant(tx *sqlx.Tx, eventID, userID, coins int) error {
+	_, err := tx.Exec(tx.Rebind(`
+		insert into participant (event_id, user_id, username, coins)
+		select ?, id, username, ? from botuser where id = ?`),
+		eventID, coins, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add user to event participants: %v", err)
+	}
+	return nil
+}
+
+// Keeps the first answer of the user to the quiz. If the answer is correct
+// and the quiz rewards the first correct answerers, the user becomes a
+// participant of the event with their share, and the quiz closes once all
+// the winners are known. Returns the number of the correct answer, zero if
+// it is not correct, and whether the quiz has closed. Returns QuizClosed or
+// AlreadyAnswered if the answer does not count.
+func (db *DB) AnswerQuiz(q *Quiz, event *Event, user *User, answer string, correct bool) (int, bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var closed bool
+	err = tx.Get(&closed, tx.Rebind("select closed from quiz where event_id = ? for update"), q.EventID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock the quiz: %v", err)
+	}
+	if closed {
+		return 0, false, QuizClosed
+	}
+
+	result, err := tx.Exec(tx.Rebind(`
+		insert into quiz_answer (event_id, user_id, answer, correct)
+		values (?, ?, ?, ?)
+		on conflict (event_id, user_id) do nothing`),
+		q.EventID, user.ID, answer, correct,
+	)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to save the answer: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return 0, false, err
+	} else if n == 0 {
+		return 0, false, AlreadyAnswered
+	}
+
+	rank := 0
+	if correct {
+		err = tx.Get(&rank, tx.Rebind(`
+			select count(*) from quiz_answer where event_id = ? and correct`),
+			q.EventID,
+		)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to count the correct answers: %v", err)
+		}
+	}
+
+	if rank > 0 && q.Winners > 0 {
+		coins := event.Coins / q.Winners
+		if rank <= event.Coins%q.Winners {
+			coins++
+		}
+		if err := addParticipant(tx, q.EventID, user.ID, coins); err != nil {
+			return 0, false, err
+		}
+		if rank >= q.Winners {
+			closed = true
+			_, err = tx.Exec(tx.Rebind("update quiz set closed = true where event_id = ?"), q.EventID)
+			if err != nil {
+				return 0, false, fmt.Errorf("failed to close the quiz: %v", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit the answer: %v", err)
+	}
+	q.Closed = closed
+	return rank, closed, nil
+}
+
+// Stops accepting answers to the quiz. If the quiz splits the coins among
+// all the correct answerers, they become the participants of the event.
+// Returns false if the quiz has been closed already.
+func (db *DB) CloseQuiz(q *Quiz, event *Event) (bool, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var closed bool
+	err = tx.Get(&closed, tx.Rebind("select closed from quiz where event_id = ? for update"), q.EventID)
+	if err != nil {
+		return false, fmt.Errorf("failed to lock the quiz: %v", err)
+	}
+	if closed {
+		return false, nil
+	}
+
+	if q.Winners == 0 {
+		var answerers []int
+		err = tx.Select(&answerers, tx.Rebind(`
+			select user_id from quiz_answer
+			where event_id = ? and correct
+			order by answered_at, user_id`),
+			q.EventID,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the correct answerers: %v", err)
+		}
+		for i, userID := range answerers {
+			coins := event.Coins / len(answerers)
+			if i < event.Coins%len(answerers) {
+				coins++
+			}
+			if err := addParticipant(tx, q.EventID, userID, coins); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	_, err = tx.Exec(tx.Rebind("update quiz set closed = true where event_id = ?"), q.EventID)
+	if err != nil {
+		return false, fmt.Errorf("failed to close the quiz: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit the quiz: %v", err)
+	}
+	q.Closed = true
+	return true, nil
+}
+
+// Makes the users opt in to the event during the registration window before
+// the start, or makes everyone participate if the window is not valid.
+func (db *DB) SetRegistration(e *Event, window Duration, keyword string) error {
+	_, err := db.Exec(db.Rebind("update event set registration = ?, keyword = ? where id = ?"), window, keyword, e.ID)
+	if err != nil {
+		return err
+	}
+	e.Registration = window
+	e.Keyword = keyword
+	return nil
+}
+
+// Registers the user for the opt-in event. The coins are calculated when the
+// event starts.
+func (db *DB) OptIn(e *Event, u *User) error {
+	result, err := db.Exec(db.Rebind(`
+		insert into participant (event_id, user_id, username, coins, opted_in_at)
+		values (?, ?, ?, 0, now())
+		on conflict (event_id, user_id) do nothing`),
+		e.ID, u.ID, u.UserName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register the user: %v", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return AlreadyOptedIn
+	}
+	return nil
+}
+
+func (db *DB) CountOptedIn(e *Event) (int, error) {
+	var count int
+	err := db.Get(&count, db.Rebind(`
+		select count(*) from participant
+		where event_id = ? and opted_in_at is not null`),
+		e.ID,
+	)
+	return count, err
+}
+
+// Returns the referral code of the user, generating one if there is none.
+func (db *DB) GetReferralCode(u *User) (string, error) {
+	if u.ReferralCode.Valid {
+		return u.ReferralCode.String, nil
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	_, err = db.Exec(db.Rebind(`
+		update botuser set referral_code = ?
+		where id = ? and referral_code is null`),
+		code, u.ID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to save the referral code: %v", err)
+	}
+
+	// another request might have generated it first
+	err = db.Get(&u.ReferralCode, db.Rebind("select referral_code from botuser where id = ?"), u.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the referral code: %v", err)
+	}
+	return u.ReferralCode.String, nil
+}
+
+func (db *DB) GetUserByReferralCode(code string) *User {
+	var user User
+	err := db.Get(&user, db.Rebind("select * from botuser where referral_code = ?"), code)
+	if err != nil {
+		return nil
+	}
+	user.exists = true
+	return &user
+}
+
+// Remembers that the referrer brought the user, unless someone else did
+// already.
+func (db *DB) AddReferral(user, referrer *User) error {
+	_, err := db.Exec(db.Rebind(`
+		insert into referral (user_id, referrer_id) values (?, ?)
+		on conflict (user_id) do nothing`),
+		user.ID, referrer.ID,
+	)
+	return err
+}
+
+// Counts the referral of the user if there is one pending. Returns the id of
+// the referrer, or zero if nothing was recorded.
+func (db *DB) RecordReferral(user *User) (int, error) {
+	var referrerID int
+	err := db.Get(&referrerID, db.Rebind(`
+		update referral set recorded_at = now()
+		where user_id = ? and recorded_at is null
+		returning referrer_id`),
+		user.ID,
+	)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record the referral: %v", err)
+	}
+	return referrerID, nil
+}
+
+// Returns the number of recorded referrals of the user.
+func (db *DB) CountReferrals(u *User) (int, error) {
+	var count int
+	err := db.Get(&count, db.Rebind(`
+		select count(*) from referral
+		where referrer_id = ? and recorded_at is not null`),
+		u.ID,
+	)
+	return count, err
+}
+
+// Returns the users who brought the most users to the group.
+func (db *DB) GetTopReferrers(limit int) ([]Referrer, error) {
+	var top []Referrer
+	err := db.Select(&top, db.Rebind(`
+		select r.referrer_id as user_id, u.username, count(*) as referrals
+		from referral r
+		join botuser u on u.id = r.referrer_id
+		where r.recorded_at is not null
+		group by r.referrer_id, u.username
+		order by referrals desc, r.referrer_id
+		limit ?`),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return top, nil
+}
+
+// A participation in an ended event, for the leaderboards.
+type ClaimRecord struct {
+	EventID  int    `db:"event_id"`
+	UserID   int    `db:"user_id"`
+	UserName string `db:"username"`
+	Coins    int    `db:"coins"`
+	Claimed  bool   `db:"claimed"`
+}
+
+// Returns the ids of the events which started after the time and have
+// ended, in the order they started, and the participations of the users who
+// are not banned in them.
+func (db *DB) GetClaimHistory(since time.Time) ([]int, []ClaimRecord, error) {
+	var events []int
+	err := db.Select(&events, db.Rebind(`
+		select id from event
+		where started_at is not null and ended_at is not null and started_at >= ?
+		order by started_at, id`),
+		since,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the events: %v", err)
+	}
+
+	var records []ClaimRecord
+	err = db.Select(&records, db.Rebind(`
+		select p.event_id, p.user_id, u.username, p.coins, p.claimed_at is not null as claimed
+		from participant p
+		join event e on e.id = p.event_id
+		join botuser u on u.id = p.user_id
+		where e.started_at is not null and e.ended_at is not null and e.started_at >= ?
+			and not u.banned
+		order by e.started_at, e.id`),
+		since,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the participants: %v", err)
+	}
+	return events, records, nil
+}
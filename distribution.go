@@ -0,0 +1,124 @@
+package skyaway
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// How the coins of an event are split among the participants.
+const (
+	DistributionEqual     = "equal"     // everyone gets the same
+	DistributionActivity  = "activity"  // by the number of recent messages in the group
+	DistributionReferrals = "referrals" // by the number of users brought to the group recently
+)
+
+// How many last days of messages and referrals count.
+const activityDays = 30
+
+// How many users /topactive shows.
+const topActiveListed = 10
+
+// Splits the coins evenly, giving a random extra coin to some of the users
+// if the coins do not divide evenly.
+func equalShares(coins, users int) []int {
+	coinsPerUser := coins / users
+	volatility := 0
+	if coins%users != 0 {
+		volatility = 1
+	}
+
+	shares := make([]int, users)
+	for i := range shares {
+		shares[i] = coinsPerUser + rand.Intn(volatility+1)
+	}
+	return shares
+}
+
+// Splits the coins evenly, giving an extra coin to each of the first users
+// if the coins do not divide evenly, so that exactly all the coins are given.
+func exactShares(coins, users int) []int {
+	shares := make([]int, users)
+	for i := range shares {
+		shares[i] = coins / users
+		if i < coins%users {
+			shares[i]++
+		}
+	}
+	return shares
+}
+
+// Splits the coins in proportion to the weights, so that no one gets more
+// than `maxShare` percent of the coins. Whatever the capped users do not get
+// goes to the others, unless everyone is capped. Users with zero weight get
+// nothing.
+func activityShares(coins int, weights []int, maxShare int) []int {
+	limit := float64(coins)
+	if maxShare > 0 {
+		limit = float64(coins) * float64(maxShare) / 100
+	}
+
+	exact := make([]float64, len(weights))
+	capped := make([]bool, len(weights))
+	remaining := float64(coins)
+	for {
+		var total float64
+		for i, w := range weights {
+			if !capped[i] {
+				total += float64(w)
+			}
+		}
+		if total == 0 {
+			break
+		}
+
+		overflow := false
+		for i, w := range weights {
+			if capped[i] {
+				continue
+			}
+			exact[i] = remaining * float64(w) / total
+			if exact[i] > limit {
+				overflow = true
+			}
+		}
+		if !overflow {
+			break
+		}
+		for i := range weights {
+			if !capped[i] && exact[i] > limit {
+				exact[i] = limit
+				capped[i] = true
+				remaining -= limit
+			}
+		}
+	}
+
+	// round down, then give the coins lost in rounding to those who lost
+	// the most
+	shares := make([]int, len(weights))
+	var sum float64
+	given := 0
+	for i, x := range exact {
+		shares[i] = int(math.Floor(x))
+		sum += x
+		given += shares[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return exact[order[a]]-float64(shares[order[a]]) > exact[order[b]]-float64(shares[order[b]])
+	})
+	for _, i := range order {
+		if given >= int(math.Round(sum)) {
+			break
+		}
+		if weights[i] > 0 && float64(shares[i]+1) <= limit {
+			shares[i]++
+			given++
+		}
+	}
+	return shares
+}
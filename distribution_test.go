@@ -0,0 +1,31 @@
+package skyaway
+
+import "testing"
+
+func TestExactSharesGiveAllCoins(t *testing.T) {
+	cases := []struct {
+		coins, users int
+	}{
+		{7, 50},
+		{50, 7},
+		{100, 10},
+		{1, 1},
+		{0, 3},
+	}
+	for _, c := range cases {
+		shares := exactShares(c.coins, c.users)
+		if len(shares) != c.users {
+			t.Errorf("%d coins for %d users: got %d shares", c.coins, c.users, len(shares))
+		}
+		sum := 0
+		for i, share := range shares {
+			sum += share
+			if i > 0 && share > shares[i-1] {
+				t.Errorf("%d coins for %d users: share %d is bigger than the earlier one", c.coins, c.users, i)
+			}
+		}
+		if sum != c.coins {
+			t.Errorf("%d coins for %d users: the shares sum to %d", c.coins, c.users, sum)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package skyaway
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// The formats of exported documents.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+func validateExportFormat(format string) error {
+	switch format {
+	case ExportCSV, ExportJSON:
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format '%s', expected csv or json", format)
+	}
+}
+
+func formatNullTime(t NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	if err := out.WriteAll(rows); err != nil {
+		return err
+	}
+	return out.Error()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Writes all participants of the event with the coins, the claim time, the
+// address and the transaction id.
+func (db *DB) ExportWinners(w io.Writer, event *Event, format string) error {
+	if err := validateExportFormat(format); err != nil {
+		return err
+	}
+
+	winners, err := db.GetWinners(event.ID, ListFilter{Sort: "id"})
+	if err != nil {
+		return fmt.Errorf("failed to get winners from db: %v", err)
+	}
+	if format == ExportJSON {
+		if winners == nil {
+			winners = []Participant{}
+		}
+		return writeJSON(w, winners)
+	}
+
+	var rows [][]string
+	for _, p := range winners {
+		rows = append(rows, []string{
+			strconv.Itoa(p.EventID),
+			strconv.Itoa(p.UserID),
+			p.UserName,
+			strconv.Itoa(p.Coins),
+			formatNullTime(p.ClaimedAt),
+			p.Address,
+			p.TxID,
+			strconv.FormatBool(p.Held),
+		})
+	}
+	return writeCSV(w, []string{
+		"event_id", "user_id", "username", "coins", "claimed_at", "address", "txid", "held",
+	}, rows)
+}
+
+// Writes all users, including the banned ones.
+func (db *DB) ExportUsers(w io.Writer, format string) error {
+	if err := validateExportFormat(format); err != nil {
+		return err
+	}
+
+	var users []User
+	for _, banned := range []bool{false, true} {
+		list, err := db.GetUsers(banned, ListFilter{Sort: "id"})
+		if err != nil {
+			return fmt.Errorf("failed to get users from db: %v", err)
+		}
+		users = append(users, list...)
+	}
+	if format == ExportJSON {
+		if users == nil {
+			users = []User{}
+		}
+		return writeJSON(w, users)
+	}
+
+	var rows [][]string
+	for _, u := range users {
+		rows = append(rows, []string{
+			strconv.Itoa(u.ID),
+			u.UserName,
+			u.FirstName,
+			u.LastName,
+			strconv.FormatBool(u.Enlisted),
+			strconv.FormatBool(u.Banned),
+			strconv.FormatBool(u.Admin),
+			u.Address,
+		})
+	}
+	return writeCSV(w, []string{
+		"id", "username", "first_name", "last_name", "enlisted", "banned", "admin", "address",
+	}, rows)
+}
+
+// Uploads the document to the private chat with the user.
+func (bot *Bot) sendDocument(ctx *Context, name string, data []byte) error {
+	doc := tgbotapi.NewDocumentUpload(int64(ctx.User.ID), tgbotapi.FileBytes{
+		Name:  name,
+		Bytes: data,
+	})
+	if _, err := bot.send(doc.ChatID, PriorityChatter, doc); err != nil {
+		return fmt.Errorf("failed to upload the document: %v", err)
+	}
+	return nil
+}
+
+// Tells where the document went if the command was not sent privately.
+func (bot *Bot) replyExported(ctx *Context) error {
+	if ctx.message.Chat.IsPrivate() {
+		return nil
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("export.sent"))
+}
+
+func exportFormat(args Args) string {
+	if args.Has("format") {
+		return args.String("format")
+	}
+	return ExportCSV
+}
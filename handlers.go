@@ -1,201 +1,415 @@
 package skyaway
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
 
 	"strconv"
+	"sync/atomic"
 	"time"
 
-	"github.com/bcampbell/fuzzytime"
 	"gopkg.in/telegram-bot-api.v4"
 )
 
 // Handler for help command
-func (bot *Bot) handleCommandHelp(ctx *Context, command, args string) error {
-	// Indentation messes up how the text is shown in chat.
-	if ctx.User.Admin {
-		return bot.Reply(ctx, `
-/start
-/help - this text
-/settings
-
-/scheduleevent [coins] [ISO timestamp, or human readable] [duration] [surprise] - start an event at timestamp and duration in hours
-/cancelevent - cancel a scheduled event
-/stopevent - stop current event
-/startevent [number of coins] [duration] - start an event immediately
-/listevent  - list the current event (admins can also see surprise events)
-/adduser [username or id] - force add user to eligible list
-/makeadmin [username] - make a user an admin
-/removeadmin [username] - remove user from admin position
-/banuser [username or id] - blacklist user from eligible list
-/unbanuser [username or id] - remove user from blacklist
-/announce [msg] - send announcement
-/announceevent - force send current scheduled or ongoing event announcement
-/usercount - return number of users
-/users - return all users in list
-/bannedusers - return all users in banned list
-/listwinners - return a list of content winners`)
-	}
-
-	return bot.Reply(ctx, `
-/start
-/help - this text
-/listevent - lists the current event`)
+func (bot *Bot) handleCommandHelp(ctx *Context, args Args) error {
+	return bot.Reply(ctx, bot.helpText(bot.Tr(ctx), ctx.User.Admin))
 }
 
 // Handler for start command
-func (bot *Bot) handleCommandStart(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandStart(ctx *Context, args Args) error {
+	if args.Has("payload") {
+		if err := bot.trackReferral(ctx.User, args.String("payload")); err != nil {
+			log.Printf("failed to track the referral of %s: %v", ctx.User.NameAndTags(), err)
+		}
+	}
+
 	helpCommand := "/help"
 	if !ctx.message.Chat.IsPrivate() {
 		helpCommand += "@" + bot.telegram.Self.UserName
 	}
-	return bot.Reply(ctx, fmt.Sprintf(
-		`Hey, this is a skycoin giveaway bot!
-Type %s for details.`,
-		helpCommand,
-	))
+	return bot.Reply(ctx, bot.Tr(ctx).T("start.greeting", helpCommand))
+}
+
+// Handler for language command
+func (bot *Bot) handleCommandLanguage(ctx *Context, args Args) error {
+	if !args.Has("language") {
+		return bot.Reply(ctx, bot.Tr(ctx).T(
+			"language.current",
+			bot.Tr(ctx).Language,
+			strings.Join(supportedLanguages(), ", "),
+		))
+	}
+
+	lang := normalizeLanguage(args.String("language"))
+	if lang == "" {
+		return bot.Reply(ctx, bot.Tr(ctx).T(
+			"language.unsupported",
+			strings.Join(supportedLanguages(), ", "),
+		))
+	}
+
+	ctx.User.Language = lang
+	if err := bot.db.PutUser(ctx.User); err != nil {
+		return fmt.Errorf("failed to save the language: %v", err)
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("language.chosen", lang))
+}
+
+// Handler for chatlanguage command
+func (bot *Bot) handleCommandChatLanguage(ctx *Context, args Args) error {
+	lang := normalizeLanguage(args.String("language"))
+	if lang == "" {
+		return bot.Reply(ctx, bot.Tr(ctx).T(
+			"language.unsupported",
+			strings.Join(supportedLanguages(), ", "),
+		))
+	}
+
+	if err := bot.db.SetChatLanguage(bot.config.ChatID, lang); err != nil {
+		return fmt.Errorf("failed to save the chat language: %v", err)
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("language.chat_chosen", lang))
 }
 
 // Handler for adduser comamnd
-func (bot *Bot) handleCommandAddUser(ctx *Context, command, args string) error {
-	identifier := args
-	dbuser := bot.db.GetUserByNameOrId(identifier)
-	if dbuser == nil {
-		return bot.Reply(ctx, "no user by that name or id")
+func (bot *Bot) handleCommandAddUser(ctx *Context, args Args) error {
+	for _, dbuser := range args.Users("user") {
+		if err := bot.enableUserVerbosely(ctx, dbuser); err != nil {
+			return err
+		}
 	}
-	return bot.enableUserVerbosely(ctx, dbuser)
+	return nil
 }
 
 // Handler for promoteuser comamnd
-func (bot *Bot) handleCommandMakeAdmin(ctx *Context, command, args string) error {
-	identifier := args
-	dbuser := bot.db.GetUserByNameOrId(identifier)
-	if dbuser == nil {
-		return bot.Reply(ctx, "no user by that name")
+func (bot *Bot) handleCommandMakeAdmin(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if bot.config.MirrorAdmins {
+		return bot.Reply(ctx, tr.T("admin.mirrored"))
 	}
+	dbuser := args.User("user")
 	dbuser.Admin = true
 
 	bot.db.PutUser(dbuser)
-	return bot.Reply(ctx, fmt.Sprintf("User %s is now an admin", identifier))
+	return bot.Reply(ctx, tr.T("admin.made", tr.NameAndTags(dbuser)))
 }
 
 // Handler for promoteuser comamnd
-func (bot *Bot) handleCommandRemoveAdmin(ctx *Context, command, args string) error {
-	identifier := args
-	dbuser := bot.db.GetUserByNameOrId(identifier)
-	if dbuser == nil {
-		return bot.Reply(ctx, "no user by that name")
+func (bot *Bot) handleCommandRemoveAdmin(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if bot.config.MirrorAdmins {
+		return bot.Reply(ctx, tr.T("admin.mirrored"))
+	}
+	dbuser := args.User("user")
+	if bot.isPermanentAdmin(dbuser.ID) {
+		return bot.Reply(ctx, tr.T("admin.permanent", tr.NameAndTags(dbuser)))
 	}
 	dbuser.Admin = false
 	bot.db.PutUser(dbuser)
-	return bot.Reply(ctx, fmt.Sprintf("User %s is not an admin anymore", identifier))
+	return bot.Reply(ctx, tr.T("admin.removed", tr.NameAndTags(dbuser)))
 }
 
 // Handler for announce command
-func (bot *Bot) handleCommandAnnounce(ctx *Context, command, args string) error {
-	msg := strings.TrimSpace(args)
-	if msg == "" {
-		return fmt.Errorf("cannot announce an empty message")
-	}
+func (bot *Bot) handleCommandAnnounce(ctx *Context, args Args) error {
+	msg := args.String("message")
 	if err := bot.Send(ctx, "yell", "text", msg); err != nil {
 		return fmt.Errorf("failed to announce: %v", err)
 	}
 
-	return bot.Reply(ctx, "done")
+	return bot.Reply(ctx, bot.Tr(ctx).T("done"))
 }
 
 // Handler for announceevent command
-func (bot *Bot) handleCommandAnnounceEvent(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandAnnounceEvent(ctx *Context, args Args) error {
 	event := bot.db.GetCurrentEvent()
 	if event == nil {
-		return bot.Reply(ctx, "nothing to announce")
+		return bot.Reply(ctx, bot.Tr(ctx).T("announce.nothing"))
 	}
 
-	md := formatEventAsMarkdown(event, true)
-	if err := bot.Send(ctx, "yell", "markdown", md); err != nil {
+	stage := StageReminder
+	if event.StartedAt.Valid {
+		stage = StageOngoing
+	}
+	if err := bot.AnnounceEvent(event, stage); err != nil {
 		return fmt.Errorf("failed to announce event: %v", err)
 	}
 
-	return bot.Reply(ctx, "done")
+	return bot.Reply(ctx, bot.Tr(ctx).T("done"))
 }
 
 // Handler for listvents command
-func (bot *Bot) handleCommandListEvent(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandListEvent(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
 	event := bot.db.GetCurrentEvent()
 
 	if event == nil {
-		return bot.Reply(ctx, "No events")
+		return bot.Reply(ctx, tr.T("listevent.none"))
 	}
 
 	// If event is a surprise event don't  show it if the
 	// user is not an admin
 	if event.Surprise && !ctx.User.Admin {
-		return bot.Reply(ctx, "No events")
+		return bot.Reply(ctx, tr.T("listevent.none"))
 	}
 
 	// Check what type of event it is
 	if event.StartedAt.Valid {
-		fmt.Println(event.StartedAt.Time.Add(event.Duration.Duration))
-		return bot.Reply(ctx, fmt.Sprintf("Current event ends at  %s", event.StartedAt.Time.Add(event.Duration.Duration)))
+		return bot.Reply(ctx, tr.T("listevent.ends_at", event.StartedAt.Time.Add(event.Duration.Duration).Format(timeFormat)))
 	} else if event.ScheduledAt.Valid {
-		return bot.Reply(ctx, fmt.Sprintf("Upcoming event starts at %s", event.ScheduledAt.Time))
+		return bot.Reply(ctx, tr.T("listevent.starts_at", event.ScheduledAt.Time.Format(timeFormat)))
 	}
 
 	log.Print("The current event is not scheduled, not started and not ended. That should not have happened.")
 	// If the user is an admin tell that there is an error
 	if ctx.User.Admin {
-		return bot.Reply(ctx, "The current event has an error.")
+		return bot.Reply(ctx, tr.T("listevent.error"))
 	}
 
-	return bot.Reply(ctx, "No events")
+	return bot.Reply(ctx, tr.T("listevent.none"))
 }
 
 // Handler for ban user command
-func (bot *Bot) handleCommandBanUser(ctx *Context, command, args string) error {
-	identifer := args
-	user := bot.db.GetUserByNameOrId(identifer)
-	if user == nil {
-		return bot.Reply(ctx, "no user by that name or id")
-	}
-	if !user.Banned {
-		user.Banned = true
-		if err := bot.db.PutUser(user); err != nil {
-			return fmt.Errorf("failed to change user status: %v", err)
-		}
+func (bot *Bot) handleCommandBanUser(ctx *Context, args Args) error {
+	user := args.User("user")
+	var until time.Time
+	if args.Has("duration") {
+		until = time.Now().Add(args.Duration("duration").Duration)
+	}
+	user.Ban(ctx.User, until, args.String("reason"))
+	if err := bot.db.PutUser(user); err != nil {
+		return fmt.Errorf("failed to change user status: %v", err)
 	}
-	return bot.Reply(ctx, user.NameAndTags())
+	return bot.Reply(ctx, bot.Tr(ctx).NameAndTags(user))
 }
 
 // Handler for unban user command
-func (bot *Bot) handleCommandUnBanUser(ctx *Context, command, args string) error {
-	identifer := args
-	user := bot.db.GetUserByNameOrId(identifer)
-	if user == nil {
-		return bot.Reply(ctx, "no user by that name or id")
-	}
+func (bot *Bot) handleCommandUnBanUser(ctx *Context, args Args) error {
+	user := args.User("user")
 	if user.Banned {
-		user.Banned = false
+		user.Unban()
 		if err := bot.db.PutUser(user); err != nil {
 			return fmt.Errorf("failed to change user status: %v", err)
 		}
 	}
-	return bot.Reply(ctx, fmt.Sprintf("unbanned user %s", user.NameAndTags()))
+	tr := bot.Tr(ctx)
+	return bot.Reply(ctx, tr.T("unban.done", tr.NameAndTags(user)))
+}
+
+// Returns the current event if it has not started yet.
+func (bot *Bot) scheduledEvent() (*Event, error) {
+	event := bot.db.GetCurrentEvent()
+	if event == nil || event.StartedAt.Valid {
+		return nil, fmt.Errorf("no scheduled event")
+	}
+	return event, nil
+}
+
+// Handler for editevent command
+func (bot *Bot) handleCommandEditEvent(ctx *Context, args Args) error {
+	event, err := bot.scheduledEvent()
+	if err != nil {
+		return err
+	}
+	if args.Has("event") && args.Event("event").ID != event.ID {
+		return fmt.Errorf("only the scheduled event %d can be edited", event.ID)
+	}
+
+	changes, err := parseKeyValues(args.String("changes"), "coins", "start", "duration", "surprise")
+	if err != nil {
+		return fmt.Errorf("could not understand: %v", err)
+	}
+
+	coins, start, duration, surprise := event.Coins, event.ScheduledAt.Time, event.Duration, event.Surprise
+	if text, ok := changes["coins"]; ok {
+		v, err := bot.parseArg(Arg{Name: "coins", Type: ArgInt}, []string{text})
+		if err != nil {
+			return fmt.Errorf("could not understand: %v", err)
+		}
+		coins = v.(int)
+	}
+	if text, ok := changes["start"]; ok {
+		if start, err = parseTime(text); err != nil {
+			return fmt.Errorf("could not understand: %v", err)
+		}
+	}
+	if text, ok := changes["duration"]; ok {
+		v, err := bot.parseArg(Arg{Name: "duration", Type: ArgDuration}, []string{text})
+		if err != nil {
+			return fmt.Errorf("could not understand: %v", err)
+		}
+		duration = v.(Duration)
+	}
+	if text, ok := changes["surprise"]; ok {
+		if surprise, err = parseBool(text); err != nil {
+			return fmt.Errorf("could not understand: %v", err)
+		}
+	}
+	if err := validateScheduleEventArgs(coins, start, duration); err != nil {
+		return fmt.Errorf("could not understand: %v", err)
+	}
+	if surprise && event.Registration.Valid {
+		return fmt.Errorf("the registration of a surprise event cannot be announced")
+	}
+	if quiz := bot.db.GetQuiz(event.ID); quiz != nil {
+		// the same as /setquiz requires
+		if quiz.Winners > coins {
+			return fmt.Errorf("the quiz has %d winners, the coins should be at least as many", quiz.Winners)
+		}
+		if quiz.AnswerFor.Duration > duration.Duration {
+			return fmt.Errorf("the quiz accepts answers for %s, the duration should be at least as long", quiz.AnswerFor.Duration)
+		}
+	}
+
+	registrationOpen := event.RegistrationOpen()
+	if err := bot.db.UpdateEvent(event, coins, start, duration, surprise); err != nil {
+		return fmt.Errorf("failed to update the event: %v", err)
+	}
+	defer bot.Reschedule()
+	log.Printf("event %d edited: %v", event.ID, changes)
+
+	if !surprise {
+		bot.AnnounceEvent(event, StageUpdated)
+	}
+	if !registrationOpen && event.RegistrationOpen() {
+		// moved into the registration window, the scheduler would go
+		// straight to the start
+		if err := bot.announceRegistration(event); err != nil {
+			return err
+		}
+	}
+	return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("edit.done"), event)
+}
+
+// Handler for setquiz command
+func (bot *Bot) handleCommandSetQuiz(ctx *Context, args Args) error {
+	event, err := bot.scheduledEvent()
+	if err != nil {
+		return err
+	}
+
+	quiz, err := parseQuiz(args.String("exact|regex|choice"), args.String("question and answers"))
+	if err != nil {
+		return err
+	}
+	if event.Registration.Valid {
+		return fmt.Errorf("the event has a registration, a quiz is open to everyone")
+	}
+	quiz.EventID = event.ID
+	quiz.Winners = args.Int("winners")
+	quiz.AnswerFor = args.Duration("answer_for")
+	if quiz.Winners < 0 || quiz.Winners > event.Coins {
+		return fmt.Errorf("winners should be between 0 and the number of coins")
+	}
+	if quiz.AnswerFor.Duration <= 0 || quiz.AnswerFor.Duration > event.Duration.Duration {
+		return fmt.Errorf("the answers should be accepted for a part of the event duration")
+	}
+
+	if err := bot.db.PutQuiz(quiz); err != nil {
+		return fmt.Errorf("failed to save the quiz: %v", err)
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("quiz.set", event.ID))
+}
+
+// Handler for removequiz command
+func (bot *Bot) handleCommandRemoveQuiz(ctx *Context, args Args) error {
+	event, err := bot.scheduledEvent()
+	if err != nil {
+		return err
+	}
+	if err := bot.db.DeleteQuiz(event.ID); err != nil {
+		return fmt.Errorf("failed to delete the quiz: %v", err)
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("quiz.removed", event.ID))
+}
+
+// Handler for setregistration command
+func (bot *Bot) handleCommandSetRegistration(ctx *Context, args Args) error {
+	event, err := bot.scheduledEvent()
+	if err != nil {
+		return err
+	}
+	window := args.Duration("window")
+	if window.Duration <= 0 {
+		return fmt.Errorf("the registration window should be positive")
+	}
+	if event.Surprise {
+		return fmt.Errorf("the registration of a surprise event cannot be announced")
+	}
+	if bot.db.GetQuiz(event.ID) != nil {
+		return fmt.Errorf("the event is a quiz, the participants are those who answer correctly")
+	}
+
+	if err := bot.db.SetRegistration(event, window, normalizeAnswer(args.String("keyword"))); err != nil {
+		return fmt.Errorf("failed to save the registration: %v", err)
+	}
+	defer bot.Reschedule()
+
+	tr := bot.Tr(ctx)
+	if event.RegistrationOpen() {
+		// the scheduler would go straight to the start
+		if err := bot.announceRegistration(event); err != nil {
+			return err
+		}
+	}
+	return bot.Reply(ctx, tr.T("registration.set", event.ID, event.RegistrationOpensAt().Format(timeFormat)))
+}
+
+// Handler for removeregistration command
+func (bot *Bot) handleCommandRemoveRegistration(ctx *Context, args Args) error {
+	event, err := bot.scheduledEvent()
+	if err != nil {
+		return err
+	}
+	if err := bot.db.SetRegistration(event, Duration{}, ""); err != nil {
+		return fmt.Errorf("failed to save the registration: %v", err)
+	}
+	defer bot.Reschedule()
+	return bot.Reply(ctx, bot.Tr(ctx).T("registration.removed", event.ID))
+}
+
+// Handler for setrollover command
+func (bot *Bot) handleCommandSetRollover(ctx *Context, args Args) error {
+	event := bot.db.GetCurrentEvent()
+	if event == nil {
+		return EventDoesNotExist
+	}
+	if args.Has("wallet|next|claimers") {
+		rollover := args.String("wallet|next|claimers")
+		if !isRollover(rollover) {
+			return fmt.Errorf("expected 'wallet', 'next' or 'claimers'")
+		}
+		if err := bot.db.SetRollover(event, rollover); err != nil {
+			return fmt.Errorf("failed to save the rollover: %v", err)
+		}
+	}
+	tr := bot.Tr(ctx)
+	var policy string
+	switch event.Rollover {
+	case RolloverNext:
+		policy = tr.T("rollover.policy_next")
+	case RolloverClaimers:
+		policy = tr.T("rollover.policy_claimers")
+	default:
+		policy = tr.T("rollover.policy_wallet")
+	}
+	return bot.Reply(ctx, tr.T("rollover.policy", event.ID, policy))
 }
 
 // Handler for cancelevent command
-func (bot *Bot) handleCommandCancelEvent(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandCancelEvent(ctx *Context, args Args) error {
 	event := bot.db.GetCurrentEvent()
 	if event == nil {
-		return bot.Reply(ctx, "nothing to cancel")
+		return bot.Reply(ctx, bot.Tr(ctx).T("cancel.nothing"))
 	}
 
 	if event.StartedAt.Valid {
 		return bot.ReplyAboutEvent(
 			ctx,
-			"the event has already started, use /stopevent instead",
+			bot.Tr(ctx).T("cancel.started"),
 			event,
 		)
 	}
@@ -204,13 +418,14 @@ func (bot *Bot) handleCommandCancelEvent(ctx *Context, command, args string) err
 		return fmt.Errorf("failed to cancel the event: %v", err)
 	}
 
-	return bot.ReplyAboutEvent(ctx, "event cancelled", event)
+	return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("cancel.done"), event)
 }
 
 // Handler for scheduleevent command
-func (bot *Bot) handleCommandScheduleEvent(ctx *Context, command, args string) error {
-	coins, start, duration, surprise, err := parseScheduleEventArgs(args)
-	if err != nil {
+func (bot *Bot) handleCommandScheduleEvent(ctx *Context, args Args) error {
+	coins, start, duration := args.Int("coins"), args.Time("start"), args.Duration("duration")
+	surprise := args.Bool("surprise")
+	if err := validateScheduleEventArgs(coins, start, duration); err != nil {
 		return fmt.Errorf("could not understand: %v", err)
 	}
 
@@ -231,18 +446,23 @@ func (bot *Bot) handleCommandScheduleEvent(ctx *Context, command, args string) e
 	defer bot.Reschedule()
 
 	if !surprise {
-		bot.AnnounceEventWithTitle(event, "A new event has been scheduled!")
+		bot.AnnounceEvent(event, StageScheduled)
 	}
-	return bot.ReplyAboutEvent(ctx, "event scheduled", event)
+	return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("schedule.done"), event)
 }
 
 // Handler for settings command
-func (bot *Bot) handleCommandSettings(ctx *Context, command, args string) error {
-	chat, err := bot.telegram.GetChat(tgbotapi.ChatConfig{bot.config.ChatID, ""})
+func (bot *Bot) handleCommandSettings(ctx *Context, args Args) error {
+	var chat tgbotapi.Chat
+	err := bot.call(0, PriorityChatter, func() (err error) {
+		chat, err = bot.telegram.GetChat(tgbotapi.ChatConfig{ChatID: bot.config.ChatID})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to get chat info: %v", err)
 	}
 
+	queued := bot.outbox.Len()
 	settings := map[string]interface{}{
 		"bot": map[string]interface{}{
 			"id":   bot.telegram.Self.ID,
@@ -253,56 +473,47 @@ func (bot *Bot) handleCommandSettings(ctx *Context, command, args string) error
 			"type":  chat.Type,
 			"title": chat.Title,
 		},
+		"outbox": map[string]interface{}{
+			"announcements": queued[PriorityAnnouncement],
+			"chatter":       queued[PriorityChatter],
+		},
 	}
 	encoded, err := json.MarshalIndent(settings, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to encode current settings into json: %v", err)
 	}
-	return bot.Reply(ctx, fmt.Sprintf("current settings: %s", string(encoded)))
+	return bot.Reply(ctx, bot.Tr(ctx).T("settings.current", string(encoded)))
 }
 
 // Handler for startevent commnad
-func (bot *Bot) handleCommandStartEvent(ctx *Context, command, args string) error {
-	words := strings.Fields(args)
-	coins, err := strconv.Atoi(words[0])
-
-	if err != nil {
-		return bot.Reply(ctx, "malformed coins format: use an integer number")
-	}
-
-	dur, err := parseDuration(words[1])
-
-	if err != nil {
-		return bot.Reply(ctx, "malformed duration format")
-	}
-
-	duration := Duration{
-		dur,
-		true,
+func (bot *Bot) handleCommandStartEvent(ctx *Context, args Args) error {
+	coins, duration := args.Int("coins"), args.Duration("duration")
+	if err := validateEventArgs(coins, duration); err != nil {
+		return fmt.Errorf("could not understand: %v", err)
 	}
 
 	event, err := bot.StartNewEvent(coins, duration)
 	if err == EventExists {
-		return bot.ReplyAboutEvent(ctx, "already have an event", event)
+		return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("start.exists"), event)
 	}
 	if err != nil {
 		return err
 	}
 
-	return bot.ReplyAboutEvent(ctx, "event started", event)
+	return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("start.done"), event)
 }
 
 // Handler for stopevent command
-func (bot *Bot) handleCommandStopEvent(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandStopEvent(ctx *Context, args Args) error {
 	event := bot.db.GetCurrentEvent()
 	if event == nil {
-		return bot.Reply(ctx, "nothing to stop")
+		return bot.Reply(ctx, bot.Tr(ctx).T("stop.nothing"))
 	}
 
 	if !event.StartedAt.Valid {
 		return bot.ReplyAboutEvent(
 			ctx,
-			"the event has not started yet, use /cancelevent instead",
+			bot.Tr(ctx).T("stop.not_started"),
 			event,
 		)
 	}
@@ -311,31 +522,32 @@ func (bot *Bot) handleCommandStopEvent(ctx *Context, command, args string) error
 		return fmt.Errorf("failed to stop the event: %v", err)
 	}
 
-	return bot.ReplyAboutEvent(ctx, "event stopped", event)
+	return bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("stop.done"), event)
 }
 
 func (bot *Bot) handleCommandCurrentEvent(ctx *Context, banned bool) error {
-	users, err := bot.db.GetUsers(banned)
+	users, err := bot.db.GetUsers(banned, ListFilter{Sort: "name"})
 
 	if err != nil {
 		return fmt.Errorf("failed to get users from db: %v", err)
 	}
 
+	tr := bot.Tr(ctx)
 	var lines []string
 	for i, user := range users {
 		lines = append(lines, fmt.Sprintf(
-			"%d. %d: %s", (i+1), user.ID, user.NameAndTags(),
+			"%d. %d: %s", (i+1), user.ID, tr.NameAndTags(&user),
 		))
 	}
 	if len(lines) > 0 {
 		return bot.Reply(ctx, strings.Join(lines, "\n"))
 	} else {
-		return bot.Reply(ctx, "no users in the list")
+		return bot.Reply(ctx, tr.T("users.none"))
 	}
 }
 
 // Handler for usercount command
-func (bot *Bot) handleCommandUserCount(ctx *Context, command, args string) error {
+func (bot *Bot) handleCommandUserCount(ctx *Context, args Args) error {
 	banned := false
 	count, err := bot.db.GetUserCount(banned)
 
@@ -346,66 +558,95 @@ func (bot *Bot) handleCommandUserCount(ctx *Context, command, args string) error
 	return bot.Reply(ctx, strconv.Itoa(count))
 }
 
+// Handler for syncusers command
+func (bot *Bot) handleCommandSyncUsers(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if atomic.LoadInt32(&bot.syncing) != 0 {
+		return bot.Reply(ctx, tr.T("sync.running"))
+	}
+	if err := bot.Reply(ctx, tr.T("sync.started")); err != nil {
+		return err
+	}
+
+	// asking telegram about every user takes long, the updates should not
+	// wait for it
+	go func() {
+		report, err := bot.syncUsers()
+		if err == SyncRunning {
+			bot.Reply(ctx, tr.T("sync.running"))
+			return
+		}
+		if err != nil {
+			log.Printf("failed to sync the membership: %v", err)
+			bot.Reply(ctx, tr.T("command.failed", err))
+			return
+		}
+		bot.Reply(ctx, tr.T(
+			"sync.done", report.Checked, report.Enlisted, report.Delisted, report.Renamed, report.Failed,
+			report.Promoted, report.Demoted,
+		))
+	}()
+	return nil
+}
+
 // Handler for users command
-func (bot *Bot) handleCommandUsersParsed(ctx *Context, banned bool) error {
-	users, err := bot.db.GetUsers(banned)
+func (bot *Bot) handleCommandUsersParsed(ctx *Context, banned bool, args Args) error {
+	filter, err := parseListFilter(args.Strings("options"), userOrders, "name")
+	if err != nil {
+		return err
+	}
+
+	users, err := bot.db.GetUsers(banned, filter)
 
 	if err != nil {
 		return fmt.Errorf("failed to get users from db: %v", err)
 	}
 
+	tr := bot.Tr(ctx)
 	var lines []string
 	for i, user := range users {
-		lines = append(lines, fmt.Sprintf(
-			"%d. %d: %s", (i+1), user.ID, user.NameAndTags(),
-		))
+		line := fmt.Sprintf("%d. %d: %s", (i + 1), user.ID, tr.NameAndTags(&user))
+		if banned {
+			line += bot.banDetails(tr, &user)
+		}
+		lines = append(lines, line)
 	}
 	if len(lines) > 0 {
-		return bot.Reply(ctx, strings.Join(lines, "\n"))
+		return bot.ReplyPaged(ctx, lines)
 	} else {
-		return bot.Reply(ctx, "no users in the list")
+		return bot.Reply(ctx, tr.T("users.none"))
 	}
 }
 
 // Handler for listwinners command
-func (bot *Bot) handleCommandListWinners(ctx *Context, command, args string) error {
-	var eventID int
-	var err error
-
-	// get last or current event id
-	if args == "last" {
-		event := bot.db.GetLastEvent()
-		eventID = event.ID
-	} else if args == "current" {
-		event := bot.db.GetCurrentEvent()
-		eventID = event.ID
-	} else {
-		// check if input argument is an integer
-		eventID, err = strconv.Atoi(args)
-		if err != nil {
-			return bot.Reply(ctx, fmt.Sprintf("invalid input argument: %s", args))
-		}
+func (bot *Bot) handleCommandListWinners(ctx *Context, args Args) error {
+	eventID := args.Event("event").ID
+	filter, err := parseListFilter(args.Strings("options"), winnerOrders, "coins")
+	if err != nil {
+		return err
 	}
 
-	winners, err := bot.db.GetWinners(eventID)
+	winners, err := bot.db.GetWinners(eventID, filter)
 
 	if err != nil {
 		return fmt.Errorf("failed to get users from db: %v", err)
 	}
 
+	tr := bot.Tr(ctx)
 	var lines []string
 	for i, winner := range winners {
 		lines = append(lines, fmt.Sprintf(
-			"%d. %d: %s: coinswon -> %d", (i+1), winner.UserID, winner.UserName, winner.Coins,
+			"%d. %d: %s: %s", (i+1), winner.UserID, winner.UserName, tr.Coins(winner.Coins),
 		))
 	}
 	if len(lines) > 0 {
-		return bot.Reply(ctx, strings.Join(lines, "\n"))
+		return bot.ReplyPaged(ctx, lines)
 	} else {
-		return bot.Reply(ctx, "no winners, that's weird")
+		return bot.Reply(ctx, tr.T("winners.none"))
 	}
 }
 func (bot *Bot) handleDirectMessageFallback(ctx *Context, text string) (bool, error) {
+	tr := bot.Tr(ctx)
 	event := bot.db.GetCurrentEvent()
 
 	if event != nil {
@@ -414,17 +655,17 @@ func (bot *Bot) handleDirectMessageFallback(ctx *Context, text string) (bool, er
 
 		if !started {
 			if canTellWhen {
-				return true, bot.Reply(ctx, fmt.Sprintf(
-					"event starts in %s",
-					niceDuration(time.Until(event.ScheduledAt.Time)),
+				return true, bot.Reply(ctx, tr.T(
+					"fallback.starts_in",
+					tr.Duration(time.Until(event.ScheduledAt.Time)),
 				))
 			} else {
-				return true, bot.Reply(ctx, "event has not started yet, come back later")
+				return true, bot.Reply(ctx, tr.T("fallback.not_started"))
 			}
 		}
 	}
 
-	return true, bot.Reply(ctx, "no upcoming events, check back later")
+	return true, bot.Reply(ctx, tr.T("fallback.no_events"))
 }
 
 func (bot *Bot) AddPrivateMessageHandler(handler MessageHandler) {
@@ -440,104 +681,633 @@ func (bot *Bot) enableUserVerbosely(ctx *Context, dbuser *User) error {
 	if err != nil {
 		return fmt.Errorf("failed to enable user: %v", err)
 	}
+	tr := bot.Tr(ctx)
 	if len(actions) > 0 {
-		return bot.Reply(ctx, strings.Join(actions, ", "))
+		var done []string
+		for _, action := range actions {
+			done = append(done, tr.T("action."+action))
+		}
+		return bot.Reply(ctx, strings.Join(done, ", "))
 	}
-	return bot.Reply(ctx, "no action required")
+	return bot.Reply(ctx, tr.T("action.none"))
 }
 
 func (bot *Bot) complainIfHaveCurrentEvent(ctx *Context) (bool, error) {
 	if event := bot.db.GetCurrentEvent(); event != nil {
 		if event.StartedAt.Valid {
-			return true, bot.ReplyAboutEvent(ctx, "already have an active event", event)
+			return true, bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("schedule.active_exists"), event)
 		} else {
-			return true, bot.ReplyAboutEvent(ctx, "already have an event in schedule", event)
+			return true, bot.ReplyAboutEvent(ctx, bot.Tr(ctx).T("schedule.scheduled_exists"), event)
 		}
 	}
 	return false, nil
 }
 
-func parseScheduleEventArgs(args string) (coins int, start time.Time, duration Duration, surprise bool, err error) {
-	words := strings.Fields(args)
-	if len(words) < 2 {
-		err = fmt.Errorf("insufficient arguments")
-		return
+// Validates the parameters of a new event.
+func validateEventArgs(coins int, duration Duration) error {
+	if coins <= 0 {
+		return fmt.Errorf("the number of coins should be positive: %d", coins)
+	}
+	if duration.Duration <= 0 {
+		return fmt.Errorf("the duration should be positive: %s", duration.Duration)
+	}
+	return nil
+}
+
+// Validates the parameters of a new scheduled event.
+func validateScheduleEventArgs(coins int, start time.Time, duration Duration) error {
+	if start.Before(time.Now()) {
+		return fmt.Errorf("%s is in the past", start.String())
+	}
+	return validateEventArgs(coins, duration)
+}
+
+// Handler for templates command
+func (bot *Bot) handleCommandTemplates(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	var lines []string
+	for _, stage := range stages {
+		source, err := bot.templateSource(stage)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, tr.T("templates.line", stage, tr.T("templates.source."+source)))
+	}
+	return bot.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// Handler for settemplate command
+func (bot *Bot) handleCommandSetTemplate(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	stage := args.String("stage")
+	if !isStage(stage) {
+		return bot.Reply(ctx, tr.T("templates.unknown_stage", strings.Join(stages, ", ")))
+	}
+
+	text := args.String("template")
+	if _, err := parseTemplate(stage, text); err != nil {
+		return bot.Reply(ctx, tr.T("templates.invalid", err))
+	}
+
+	if err := bot.db.PutTemplate(stage, text); err != nil {
+		return fmt.Errorf("failed to save the template: %v", err)
+	}
+	if err := bot.loadTemplates(); err != nil {
+		return err
+	}
+	return bot.Reply(ctx, tr.T("templates.saved", stage))
+}
+
+// Handler for resettemplate command
+func (bot *Bot) handleCommandResetTemplate(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	stage := args.String("stage")
+	if !isStage(stage) {
+		return bot.Reply(ctx, tr.T("templates.unknown_stage", strings.Join(stages, ", ")))
+	}
+
+	if err := bot.db.DeleteTemplate(stage); err != nil {
+		return fmt.Errorf("failed to delete the template: %v", err)
+	}
+	if err := bot.loadTemplates(); err != nil {
+		return err
+	}
+	return bot.Reply(ctx, tr.T("templates.reset", stage))
+}
+
+// Handler for previewtemplate command
+func (bot *Bot) handleCommandPreviewTemplate(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	stage := args.String("stage")
+	if !isStage(stage) {
+		return bot.Reply(ctx, tr.T("templates.unknown_stage", strings.Join(stages, ", ")))
+	}
+
+	tmpl := bot.template(stage)
+	if args.Has("template") {
+		var err error
+		if tmpl, err = parseTemplate(stage, args.String("template")); err != nil {
+			return bot.Reply(ctx, tr.T("templates.invalid", err))
+		}
+	}
+
+	event := bot.db.GetCurrentEvent()
+	if event == nil {
+		event = sampleEvent()
+	}
+	groupTr := bot.groupTr()
+	a := newAnnouncement(groupTr, event, stage)
+	var err error
+	if a.Stats, err = bot.formatEventStatsAsMarkdown(groupTr, event); err != nil {
+		return err
+	}
+	md, err := renderTemplate(tmpl, a)
+	if err != nil {
+		return bot.Reply(ctx, tr.T("templates.invalid", err))
+	}
+	return bot.Send(ctx, "whisper", "markdown", md)
+}
+
+// Handler for paid command
+func (bot *Bot) handleCommandPaid(ctx *Context, args Args) error {
+	event, txid := args.Event("event"), args.String("txid")
+	if err := validateTxID(txid); err != nil {
+		return err
+	}
+	var ids []int
+	for _, u := range args.Users("users") {
+		ids = append(ids, u.ID)
+	}
+	n, err := bot.db.SetPaid(event, txid, ids)
+	if err != nil {
+		return err
+	}
+	log.Printf("event %d payouts paid by %s: %d", event.ID, txid, n)
+	return bot.Reply(ctx, bot.Tr(ctx).N("paid.done", n, n, event.ID))
+}
+
+// Handler for exportwinners command
+func (bot *Bot) handleCommandExportWinners(ctx *Context, args Args) error {
+	event := args.Event("event")
+	format := exportFormat(args)
+
+	var buf bytes.Buffer
+	if err := bot.db.ExportWinners(&buf, event, format); err != nil {
+		return err
+	}
+	if err := bot.sendDocument(ctx, fmt.Sprintf("winners-%d.%s", event.ID, format), buf.Bytes()); err != nil {
+		return err
 	}
+	return bot.replyExported(ctx)
+}
+
+// Handler for exportusers command
+func (bot *Bot) handleCommandExportUsers(ctx *Context, args Args) error {
+	format := exportFormat(args)
 
-	coins, err = strconv.Atoi(words[0])
+	var buf bytes.Buffer
+	if err := bot.db.ExportUsers(&buf, format); err != nil {
+		return err
+	}
+	if err := bot.sendDocument(ctx, "users."+format, buf.Bytes()); err != nil {
+		return err
+	}
+	return bot.replyExported(ctx)
+}
+
+// Handler for events command
+func (bot *Bot) handleCommandEvents(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	n := defaultEventsListed
+	if args.Has("n") {
+		n = args.Int("n")
+	}
+	if n < 1 || n > maxEventsListed {
+		return bot.Reply(ctx, tr.T("events.bad_count", maxEventsListed))
+	}
+
+	summaries, err := bot.db.GetEventSummaries(n)
 	if err != nil {
-		err = fmt.Errorf("could not parse the number of coins: %v", err)
-		return
+		return err
 	}
 
-	surprise = words[len(words)-1] == "surprise"
-	if surprise {
-		// cut out the first and last word
-		words = words[1 : len(words)-1]
+	var lines []string
+	for _, s := range summaries {
+		lines = append(lines, tr.T("events.line",
+			s.ID,
+			s.StartedAt.Time.Format(dateFormat),
+			tr.Coins(s.Coins),
+			tr.Duration(s.EndedAt.Time.Sub(s.StartedAt.Time)),
+			s.Participants,
+			s.ClaimRate(),
+			tr.Coins(s.Coins-s.Claimed),
+		))
+	}
+	if len(lines) > 0 {
+		return bot.ReplyPaged(ctx, lines)
 	} else {
-		// cut out the first word
-		words = words[1:len(words)]
+		return bot.Reply(ctx, tr.T("events.none"))
+	}
+}
+
+// Handler for eventstats command
+func (bot *Bot) handleCommandEventStats(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	event := args.Event("event")
+	if !event.StartedAt.Valid {
+		return bot.Reply(ctx, tr.T("eventstats.not_started"))
 	}
 
-	dur, err := parseDuration(words[len(words)-1])
+	s, err := bot.db.GetEventSummary(event)
+	if err != nil {
+		return err
+	}
+	first, claimed, err := bot.db.TimeToFirstClaim(event)
 	if err != nil {
-		err = fmt.Errorf("malformed duration format: %s", words[len(words)-1])
-		return
+		return err
+	}
+	width := event.Duration.Duration / histogramBuckets
+	if width < time.Second {
+		width = time.Second
+	}
+	histogram, err := bot.db.ClaimHistogram(event, width)
+	if err != nil {
+		return err
 	}
 
-	duration = Duration{
-		dur,
-		true,
+	var fields []string
+	fields = appendField(fields, tr.T("field.event"), "#%d", s.ID)
+	fields = appendField(fields, tr.T("field.coins"), "%s", tr.Coins(s.Coins))
+	fields = appendField(fields, tr.T("field.participants"), "%d", s.Participants)
+	fields = appendField(fields, tr.T("field.claimers"), "%d (%d%%)", s.Claimers, s.ClaimRate())
+	fields = appendField(fields, tr.T("field.claimed"), "%s", tr.Coins(s.Claimed))
+	fields = appendField(fields, tr.T("field.unclaimed"), "%s", tr.Coins(s.Coins-s.Claimed))
+	if claimed {
+		fields = appendField(fields, tr.T("field.first_claim"), "%s", tr.Duration(first))
+		fields = append(fields, "", tr.T("eventstats.histogram"))
+		fields = append(fields, formatHistogram(tr, histogram, width)...)
 	}
+	return bot.Send(ctx, "reply", "markdown", strings.Join(fields, "\n"))
+}
 
-	timestr := strings.Join(words, " ")
-	ft, _, err := fuzzytime.Extract(timestr)
-	if ft.Empty() {
-		err = fmt.Errorf("unsupported datetime format: %v", timestr)
-		return
+// Handler for mystatus command
+func (bot *Bot) handleCommandMyStatus(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	var lines []string
+	switch {
+	case ctx.User.Banned:
+		lines = append(lines, tr.T("status.banned"))
+	case ctx.User.Enlisted:
+		lines = append(lines, tr.T("status.enlisted"))
+	default:
+		lines = append(lines, tr.T("status.not_enlisted"))
 	}
 
-	var hour, minute, second int
-	var loc *time.Location
-	if ft.Time.HasHour() {
-		hour = ft.Time.Hour()
+	event := bot.db.GetCurrentEvent()
+	switch {
+	case event == nil || !event.StartedAt.Valid && event.Surprise:
+		lines = append(lines, tr.T("status.no_event"))
+	case !event.StartedAt.Valid:
+		lines = append(lines, tr.T("fallback.starts_in", tr.Duration(time.Until(event.ScheduledAt.Time))))
+	default:
+		p, err := bot.db.GetParticipant(ctx.User, event)
+		if err != nil {
+			return err
+		}
+		switch {
+		case p == nil:
+			lines = append(lines, tr.T("status.not_participating"))
+		case p.ClaimedAt.Valid:
+			lines = append(lines, tr.T("status.claimed", tr.Coins(p.Coins)))
+		default:
+			lines = append(lines, tr.T("status.to_claim", tr.Coins(p.Coins)))
+		}
 	}
-	if ft.Time.HasMinute() {
-		minute = ft.Time.Minute()
+	return bot.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// Handler for history command
+func (bot *Bot) handleCommandHistory(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	participations, err := bot.db.GetParticipations(ctx.User)
+	if err != nil {
+		return err
 	}
-	if ft.Time.HasSecond() {
-		second = ft.Time.Second()
+
+	var lines []string
+	for _, p := range participations {
+		var status string
+		switch {
+		case p.TxID != "":
+			status = tr.T("history.paid", p.TxID)
+		case p.ClaimedAt.Valid:
+			status = tr.T("history.claimed")
+		default:
+			status = tr.T("history.not_claimed")
+		}
+		lines = append(lines, tr.T("history.line",
+			p.EventID, p.StartedAt.Time.Format(dateFormat), tr.Coins(p.Coins), status,
+		))
 	}
-	if ft.Time.HasTZOffset() {
-		loc = time.FixedZone("", ft.Time.TZOffset())
+	if len(lines) > 0 {
+		return bot.ReplyPaged(ctx, lines)
 	} else {
-		loc = time.UTC
+		return bot.Reply(ctx, tr.T("history.none"))
 	}
+}
 
-	if ft.HasFullDate() {
-		start = time.Date(
-			ft.Date.Year(),
-			time.Month(ft.Date.Month()),
-			ft.Date.Day(),
-			hour, minute, second, 0,
-			loc,
-		)
-	} else {
-		year, month, day := time.Now().In(loc).Date()
-		start = time.Date(
-			year, month, day,
-			hour, minute, second, 0,
-			loc,
-		)
-		if start.Before(time.Now()) {
-			start = start.AddDate(0, 0, 1)
+// Handler for setaddress command
+func (bot *Bot) handleCommandSetAddress(ctx *Context, args Args) error {
+	// the address should not be tied to the name in the group
+	if !ctx.message.Chat.IsPrivate() {
+		return bot.Reply(ctx, bot.Tr(ctx).T("address.private"))
+	}
+	address := args.String("address")
+	if err := bot.db.SetAddress(ctx.User, address); err != nil {
+		return err
+	}
+	return bot.Reply(ctx, bot.Tr(ctx).T("address.saved", address))
+}
+
+// Handler for myaddress command
+func (bot *Bot) handleCommandMyAddress(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if !ctx.message.Chat.IsPrivate() {
+		return bot.Reply(ctx, tr.T("address.private"))
+	}
+	if ctx.User.Address == "" {
+		return bot.Reply(ctx, tr.T("address.none"))
+	}
+	return bot.Reply(ctx, tr.T("address.current", ctx.User.Address))
+}
+
+// Handler for requireaddress command
+func (bot *Bot) handleCommandRequireAddress(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if !args.Has("on|off") {
+		if bot.db.GetRequireAddress(bot.config.ChatID) {
+			return bot.Reply(ctx, tr.T("requireaddress.on"))
 		}
+		return bot.Reply(ctx, tr.T("requireaddress.off"))
 	}
 
-	if start.Before(time.Now()) {
-		err = fmt.Errorf("%s is in the past", start.String())
-		return
+	var require bool
+	switch args.String("on|off") {
+	case "on":
+		require = true
+	case "off":
+		require = false
+	default:
+		return fmt.Errorf("expected 'on' or 'off'")
+	}
+	if err := bot.db.SetRequireAddress(bot.config.ChatID, require); err != nil {
+		return fmt.Errorf("failed to save the setting: %v", err)
+	}
+	if require {
+		return bot.Reply(ctx, tr.T("requireaddress.on"))
+	}
+	return bot.Reply(ctx, tr.T("requireaddress.off"))
+}
+
+// The number of clusters /suspicious shows at once.
+const suspiciousClustersShown = 10
+
+// Handler for suspicious command
+func (bot *Bot) handleCommandSuspicious(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	clusters, err := bot.db.GetSuspiciousClusters()
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return bot.Reply(ctx, tr.T("suspicious.none"))
+	}
+
+	for i, cluster := range clusters {
+		if i == suspiciousClustersShown {
+			return bot.Reply(ctx, tr.T("suspicious.more", len(clusters)-i))
+		}
+
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, id := range cluster {
+			user := bot.db.GetUser(id)
+			if user == nil {
+				continue
+			}
+			name := tr.NameAndTags(user)
+			ban, err := bot.CallbackButton(tr.T("suspicious.ban", name), "ban", strconv.Itoa(id))
+			if err != nil {
+				return err
+			}
+			release, err := bot.CallbackButton(tr.T("suspicious.release", name), "release", strconv.Itoa(id))
+			if err != nil {
+				return err
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(ban, release))
+		}
+
+		msg := tgbotapi.NewMessage(ctx.message.Chat.ID, tr.T("suspicious.cluster", i+1, len(cluster)))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		bot.post(msg.ChatID, PriorityChatter, msg)
+	}
+	return nil
+}
+
+// Handler for distribution command
+func (bot *Bot) handleCommandDistribution(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	if args.Has("equal|activity|referrals") {
+		distribution, maxShare := args.String("equal|activity|referrals"), args.Int("max_share")
+		switch distribution {
+		case DistributionEqual, DistributionActivity, DistributionReferrals:
+		default:
+			return fmt.Errorf("expected 'equal', 'activity' or 'referrals'")
+		}
+		if maxShare < 0 || maxShare > 100 {
+			return fmt.Errorf("max share should be between 0 and 100 percent")
+		}
+		if err := bot.db.SetDistribution(bot.config.ChatID, distribution, maxShare); err != nil {
+			return fmt.Errorf("failed to save the setting: %v", err)
+		}
+	}
+
+	distribution, maxShare := bot.db.GetDistribution(bot.config.ChatID)
+	var text string
+	switch distribution {
+	case DistributionActivity:
+		text = tr.T("distribution.activity", activityDays)
+	case DistributionReferrals:
+		text = tr.T("distribution.referrals", activityDays)
+	default:
+		return bot.Reply(ctx, tr.T("distribution.equal"))
+	}
+	if maxShare > 0 {
+		text += tr.T("distribution.capped", maxShare)
+	}
+	return bot.Reply(ctx, text)
+}
+
+// Handler for leaderboard command
+func (bot *Bot) handleCommandLeaderboard(ctx *Context, args Args) error {
+	// either can go first, or alone
+	var options []string
+	for _, name := range []string{"coins|claims|streak", "week|month|year|all"} {
+		if args.Has(name) {
+			options = append(options, args.String(name))
+		}
+	}
+
+	kind, period := LeaderboardCoins, "all"
+	for _, option := range options {
+		switch option = strings.ToLower(option); option {
+		case LeaderboardCoins, LeaderboardClaims, LeaderboardStreak:
+			kind = option
+		default:
+			if _, ok := leaderboardPeriods[option]; !ok {
+				return fmt.Errorf("unknown option '%s', expected coins, claims, streak, week, month, year or all", option)
+			}
+			period = option
+		}
+	}
+
+	if !ctx.message.Chat.IsPrivate() && !bot.leaderboards.allowInGroup() {
+		// answering would be as spammy as the requests
+		return nil
+	}
+
+	board, err := bot.leaderboard(kind, period)
+	if err != nil {
+		return err
+	}
+
+	tr := bot.Tr(ctx)
+	if len(board) == 0 {
+		return bot.Reply(ctx, tr.T("leaderboard.none"))
+	}
+	lines := []string{tr.T("leaderboard."+kind, tr.T("leaderboard.period."+period))}
+	for i, e := range board {
+		name := e.UserName
+		if name == "" {
+			name = strconv.Itoa(e.UserID)
+		}
+		var score string
+		switch kind {
+		case LeaderboardCoins:
+			score = tr.Coins(e.Score)
+		case LeaderboardClaims:
+			score = tr.N("leaderboard.claimed", e.Score, e.Score, e.Of)
+		case LeaderboardStreak:
+			score = tr.N("leaderboard.in_a_row", e.Score)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, name, score))
+	}
+	return bot.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// Handler for reminders command
+func (bot *Bot) handleCommandReminders(ctx *Context, args Args) error {
+	if args.Has("on|off") {
+		var on bool
+		switch args.String("on|off") {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return fmt.Errorf("expected 'on' or 'off'")
+		}
+		if err := bot.db.SetReminders(ctx.User, on); err != nil {
+			return fmt.Errorf("failed to save the setting: %v", err)
+		}
+	}
+
+	tr := bot.Tr(ctx)
+	if !ctx.User.Reminders {
+		return bot.Reply(ctx, tr.T("reminders.off"))
 	}
+	if ctx.User.Unreachable {
+		return bot.Reply(ctx, tr.T("reminders.unreachable"))
+	}
+	return bot.Reply(ctx, tr.T("reminders.on"))
+}
 
-	return
+// Handler for referral command
+func (bot *Bot) handleCommandReferral(ctx *Context, args Args) error {
+	code, err := bot.db.GetReferralCode(ctx.User)
+	if err != nil {
+		return err
+	}
+	count, err := bot.db.CountReferrals(ctx.User)
+	if err != nil {
+		return fmt.Errorf("failed to count the referrals: %v", err)
+	}
+	tr := bot.Tr(ctx)
+	return bot.Reply(ctx, tr.T("referral.link", bot.referralLink(code), tr.N("referral.count", count)))
+}
+
+// Handler for referrals command
+func (bot *Bot) handleCommandReferrals(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	top, err := bot.db.GetTopReferrers(topReferrersListed)
+	if err != nil {
+		return fmt.Errorf("failed to get the referrers from db: %v", err)
+	}
+	if len(top) == 0 {
+		return bot.Reply(ctx, tr.T("referrals.none"))
+	}
+
+	var lines []string
+	for i, r := range top {
+		name := r.UserName
+		if name == "" {
+			name = strconv.Itoa(r.UserID)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, name, tr.N("referral.count", r.Referrals)))
+	}
+	return bot.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// Handler for topactive command
+func (bot *Bot) handleCommandTopActive(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	days := activityDays
+	if args.Has("days") {
+		days = args.Int("days")
+	}
+	if days < 1 {
+		return fmt.Errorf("days should be a positive number")
+	}
+
+	top, err := bot.db.GetTopActive(days, topActiveListed)
+	if err != nil {
+		return fmt.Errorf("failed to get the activity from db: %v", err)
+	}
+	if len(top) == 0 {
+		return bot.Reply(ctx, tr.T("topactive.none", days))
+	}
+
+	lines := []string{tr.N("topactive.header", days)}
+	for i, a := range top {
+		name := a.UserName
+		if name == "" {
+			name = strconv.Itoa(a.UserID)
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s — %s", i+1, name, tr.N("topactive.messages", a.Messages)))
+	}
+	return bot.Reply(ctx, strings.Join(lines, "\n"))
+}
+
+// Handler for captcha command
+func (bot *Bot) handleCommandCaptcha(ctx *Context, args Args) error {
+	tr := bot.Tr(ctx)
+	kinds, dm := bot.db.GetCaptcha(bot.config.ChatID)
+
+	if settings := args.Strings("off|button|arithmetic|dm|group"); len(settings) > 0 {
+		kinds = nil
+		for _, setting := range settings {
+			switch {
+			case setting == "off":
+				kinds, dm = nil, false
+			case setting == "dm":
+				dm = true
+			case setting == "group":
+				dm = false
+			case isCaptchaKind(setting):
+				kinds = append(kinds, setting)
+			default:
+				return fmt.Errorf("unknown challenge: %s", setting)
+			}
+		}
+		if err := bot.db.SetCaptcha(bot.config.ChatID, kinds, dm); err != nil {
+			return fmt.Errorf("failed to save the challenges: %v", err)
+		}
+	}
+
+	if len(kinds) == 0 {
+		return bot.Reply(ctx, tr.T("captcha.off"))
+	}
+	where := tr.T("captcha.in_group")
+	if dm {
+		where = tr.T("captcha.in_dm")
+	}
+	return bot.Reply(ctx, tr.T("captcha.on", strings.Join(kinds, ", "), where, tr.Duration(bot.captchaTimeout())))
 }
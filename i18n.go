@@ -0,0 +1,221 @@
+package skyaway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Maps message keys to format strings. Plural-aware messages have their
+// forms separated by "|", in the order defined by the plural rule of the
+// language.
+type Catalog map[string]string
+
+const defaultLanguage = "en"
+
+var catalogs = map[string]Catalog{
+	"en": catalogEn,
+	"es": catalogEs,
+	"ru": catalogRu,
+	"zh": catalogZh,
+}
+
+// Returns the index of the plural form to use for `n` in `lang`.
+func pluralForm(lang string, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case "ru":
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20):
+			return 1
+		default:
+			return 2
+		}
+	case "zh":
+		return 0
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
+
+// Returns the supported language for a telegram language code like "en-US",
+// or an empty string if the language is not supported.
+func normalizeLanguage(code string) string {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return ""
+}
+
+func supportedLanguages() []string {
+	var langs []string
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Formats messages in a single language.
+type Translator struct {
+	Language string
+}
+
+var defaultTranslator = Translator{defaultLanguage}
+
+func (t Translator) lookup(key string) string {
+	if format, ok := catalogs[t.Language][key]; ok {
+		return format
+	}
+	if format, ok := catalogs[defaultLanguage][key]; ok {
+		return format
+	}
+	return key
+}
+
+// Formats the message `key` with `args`.
+func (t Translator) T(key string, args ...interface{}) string {
+	format := t.lookup(key)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Formats the plural-aware message `key` choosing the form for `n`. If no
+// `args` are given, `n` is used as the only argument.
+func (t Translator) N(key string, n int, args ...interface{}) string {
+	forms := strings.Split(t.lookup(key), "|")
+	i := pluralForm(t.Language, n)
+	if i >= len(forms) {
+		i = len(forms) - 1
+	}
+	if len(args) == 0 {
+		args = []interface{}{n}
+	}
+	return fmt.Sprintf(forms[i], args...)
+}
+
+func (t Translator) Coins(n int) string {
+	return t.N("unit.coins", n)
+}
+
+func (t Translator) Duration(d time.Duration) string {
+	if d < 0 {
+		return d.String()
+	}
+
+	var hours, minutes, seconds int
+	seconds = int(d.Seconds())
+	hours, seconds = seconds/3600, seconds%3600
+	minutes, seconds = seconds/60, seconds%60
+
+	var parts []string
+	if hours > 0 {
+		parts = append(parts, t.N("unit.hours", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, t.N("unit.minutes", minutes))
+	}
+	if (seconds > 0 && hours == 0) || len(parts) == 0 {
+		parts = append(parts, t.N("unit.seconds", seconds))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (t Translator) NameAndTags(u *User) string {
+	var tags []string
+	if u.Banned && u.BannedUntil.Valid {
+		tags = append(tags, t.T("tag.banned_until", u.BannedUntil.Time.Format(timeFormat)))
+	} else if u.Banned {
+		tags = append(tags, t.T("tag.banned"))
+	}
+	if u.Admin {
+		tags = append(tags, t.T("tag.admin"))
+	}
+	if u.FormerName != "" {
+		tags = append(tags, t.T("tag.renamed", u.FormerName))
+	}
+	switch u.Verification {
+	case VerificationPending, VerificationFailed, VerificationTimeout:
+		tags = append(tags, t.T("tag."+u.Verification))
+	}
+
+	// If username is hidden use userid
+	identifier := u.UserName
+	if identifier == "" {
+		identifier = fmt.Sprint(u.ID)
+	}
+
+	if len(tags) > 0 {
+		return fmt.Sprintf("%s (%s)", identifier, strings.Join(tags, ", "))
+	}
+
+	return identifier
+}
+
+// Describes the command in the language, falling back to the description
+// given in the command itself.
+func (t Translator) Describe(c *Command) string {
+	key := "cmd." + c.Command
+	if format, ok := catalogs[t.Language][key]; ok {
+		return format
+	}
+	return c.Description
+}
+
+// Returns the translator for the group chat.
+func (bot *Bot) groupTr() Translator {
+	if lang := bot.db.GetChatLanguage(bot.config.ChatID); lang != "" {
+		return Translator{lang}
+	}
+	if lang := normalizeLanguage(bot.config.Language); lang != "" {
+		return Translator{lang}
+	}
+	return defaultTranslator
+}
+
+// Returns the translator for private messages the user has not asked for.
+func (bot *Bot) userTr(u *User) Translator {
+	if u.Language != "" {
+		return Translator{u.Language}
+	}
+	return bot.groupTr()
+}
+
+// Returns the translator for replies in the context. Private chats use the
+// language chosen by the user or the language of the telegram client, the
+// group uses the language of the chat.
+func (bot *Bot) Tr(ctx *Context) Translator {
+	if ctx.message == nil || !ctx.message.Chat.IsPrivate() {
+		return bot.groupTr()
+	}
+
+	if ctx.User != nil && ctx.User.Language != "" {
+		return Translator{ctx.User.Language}
+	}
+
+	from := ctx.message.From
+	if ctx.callback != nil {
+		from = ctx.callback.From
+	}
+	if from != nil {
+		if lang := normalizeLanguage(from.LanguageCode); lang != "" {
+			return Translator{lang}
+		}
+	}
+
+	return bot.groupTr()
+}
@@ -0,0 +1,242 @@
+package skyaway
+
+var catalogEn = Catalog{
+	"unit.coins":   "%d coin|%d coins",
+	"unit.hours":   "%d hour|%d hours",
+	"unit.minutes": "%d minute|%d minutes",
+	"unit.seconds": "%d second|%d seconds",
+
+	"tag.banned":       "banned",
+	"tag.banned_until": "banned until %s",
+	"tag.renamed":      "formerly %s",
+	"tag.admin":        "admin",
+	"tag.pending":      "pending verification",
+	"tag.failed":       "failed verification",
+	"tag.timeout":      "verification timed out",
+
+	"field.coins":         "coins",
+	"field.started":       "started",
+	"field.will_start":    "will start",
+	"field.duration":      "duration",
+	"field.surprise":      "surprise",
+	"field.participants":  "participants",
+	"field.claimed":       "claimed",
+	"field.unclaimed":     "unclaimed",
+	"field.claimers_left": "claimers left",
+	"field.event":         "event",
+	"field.claimers":      "claimers",
+	"field.first_claim":   "first claim after",
+
+	"value.time_ago":  "%s (%s ago)",
+	"value.time_in":   "%s (in %s)",
+	"value.ended_ago": "%s (ended %s ago)",
+	"value.ends_in":   "%s (ends in %s)",
+	"value.yes":       "yes",
+	"value.no":        "no",
+
+	"title.scheduled": "A new event has been scheduled!",
+	"title.updated":   "Event has been changed",
+	"title.reminder":  "Event is scheduled",
+	"title.started":   "Event has started!",
+	"title.ongoing":   "Event is ongoing",
+	"title.ended":     "Event has ended!",
+	"title.cancelled": "The scheduled event has been cancelled",
+
+	"done":             "done",
+	"command.failed":   "command failed: %v",
+	"command.usage":    "usage: %s",
+	"start.greeting":   "Hey, this is a skycoin giveaway bot!\nType %s for details.",
+	"settings.current": "current settings: %s",
+
+	"language.current":     "your language is %s, available: %s",
+	"language.unsupported": "unsupported language, available: %s",
+	"language.chosen":      "your language is %s now",
+	"language.chat_chosen": "the language of the group is %s now",
+
+	"action.created":     "created",
+	"action.unbanned":    "unbanned",
+	"action.enlisted":    "enlisted",
+	"action.verified":    "verified",
+	"action.none":        "no action required",
+	"adduser.not_member": "that user is not a member of the chat",
+	"admin.made":         "User %s is now an admin",
+	"admin.removed":      "User %s is not an admin anymore",
+	"admin.mirrored":     "admins follow the administrators of the group, change them in telegram",
+	"admin.permanent":    "%s is a permanent admin and cannot be removed",
+	"unban.done":         "unbanned user %s",
+	"ban.reason":         "reason: %s",
+	"ban.by":             "by %s",
+	"ban.left":           "%s left",
+	"ban.permanent":      "permanently",
+	"users.none":         "no users in the list",
+	"sync.started":       "checking the membership of all users, this may take a while",
+	"sync.running":       "the membership is being checked already, wait for it to finish",
+	"sync.done":          "users checked: %d, enlisted: %d, delisted: %d, renamed: %d, failed: %d, promoted: %d, demoted: %d",
+
+	"address.saved":            "your payout address is %s now",
+	"address.current":          "your payout address is %s",
+	"address.none":             "you have not saved a payout address, use /setaddress",
+	"address.private":          "send this to me in a private chat, so that your address is not shown to everyone",
+	"requireaddress.on":        "only users with a saved address participate in events",
+	"requireaddress.off":       "users participate in events without a saved address",
+	"distribution.equal":       "the coins are split equally among the participants",
+	"distribution.activity":    "the coins are split by the messages in the group in the last %d days",
+	"distribution.referrals":   "the coins are split by the users brought to the group in the last %d days",
+	"distribution.capped":      ", at most %d%% to one participant",
+	"topactive.none":           "nobody posted to the group in the last %d days",
+	"topactive.header":         "most active in the last %d day:|most active in the last %d days:",
+	"topactive.messages":       "%d message|%d messages",
+	"referral.link":            "Your invite link: %s\nYou have brought %s.",
+	"referral.count":           "%d user|%d users",
+	"referrals.none":           "nobody has invited anyone yet",
+	"leaderboard.none":         "nobody has claimed anything yet",
+	"leaderboard.coins":        "Top earners, %s:",
+	"leaderboard.claims":       "Most consistent claimers, %s:",
+	"leaderboard.streak":       "Longest claim streaks, %s:",
+	"leaderboard.period.week":  "last week",
+	"leaderboard.period.month": "last month",
+	"leaderboard.period.year":  "last year",
+	"leaderboard.period.all":   "all time",
+	"leaderboard.claimed":      "%d of %d event|%d of %d events",
+	"leaderboard.in_a_row":     "%d event in a row|%d events in a row",
+
+	"claim.done":         "%s claimed to %s",
+	"claim.held":         "%s claimed to %s, the payout is held for a review",
+	"claim.send_address": "You can claim %s, send me your skycoin address.",
+	"claim.offer":        "You can claim %s, send me your skycoin address or use the saved one: %s",
+	"claim.use_saved":    "Use saved address",
+	"claim.event_over":   "the event is over",
+
+	"reminder.claim":        "Don't forget to claim %s, the event ends in %s. Send me your skycoin address. Turn these reminders off with /reminders off",
+	"reminders.on":          "I will remind you privately to claim before the events end. Turn it off with /reminders off",
+	"reminders.off":         "You do not get reminders to claim. Turn them on with /reminders on",
+	"reminders.unreachable": "Reminders to claim are on, but I cannot message you privately. Start a chat with me to get them.",
+
+	"status.enlisted":          "You are in the giveaway list.",
+	"status.not_enlisted":      "You are not in the giveaway list, join the group to take part.",
+	"status.banned":            "You are banned from the giveaways.",
+	"status.no_event":          "There is no event at the moment.",
+	"status.not_participating": "You do not participate in the current event, you were not in the list when it started.",
+	"status.claimed":           "You have claimed %s in the current event.",
+	"status.to_claim":          "You can claim %s in the current event.",
+
+	"history.line":        "#%d %s: %s, %s",
+	"history.paid":        "paid, txid %s",
+	"history.claimed":     "claimed",
+	"history.not_claimed": "not claimed",
+	"history.none":        "You have not participated in any events yet.",
+
+	"events.none":            "No finished events",
+	"events.bad_count":       "the number of events should be from 1 to %d",
+	"events.line":            "#%d %s: %s for %s, participants: %d, claimed by %d%%, unclaimed: %s",
+	"eventstats.not_started": "the event has not started",
+	"eventstats.histogram":   "Claims over time:",
+
+	"suspicious.none":     "No shared claim addresses wait for a review",
+	"suspicious.cluster":  "Cluster #%d: %d users claimed to the same or linked addresses",
+	"suspicious.more":     "%d more clusters are not shown, ban or release these first",
+	"suspicious.ban":      "Ban %s",
+	"suspicious.release":  "Release %s",
+	"suspicious.banned":   "%s is banned",
+	"suspicious.released": "the payouts of %s are released",
+
+	"export.sent": "the document has been sent to you privately",
+	"paid.done":   "%d payout in event %d recorded as paid|%d payouts in event %d recorded as paid",
+
+	"pager.page":      "page %d of %d",
+	"pager.prev":      "« Prev",
+	"pager.next":      "Next »",
+	"pager.expired":   "this list is too old, request it again",
+	"pager.not_yours": "this list was requested by someone else",
+
+	"captcha.button":     "%s, press the button within %s to take part in the giveaways.",
+	"captcha.arithmetic": "%s, how much is %d + %d? Answer within %s to take part in the giveaways.",
+	"captcha.press":      "I am not a bot",
+	"captcha.passed":     "%s is verified, welcome!",
+	"captcha.failed":     "%s has failed the verification.",
+	"captcha.timed_out":  "%s has not passed the verification in time.",
+	"captcha.not_yours":  "this challenge is for someone else",
+	"captcha.expired":    "this challenge has expired",
+	"captcha.off":        "new members are enlisted without a challenge",
+	"captcha.on":         "new members get a challenge (%s) %s and have %s to pass it",
+	"captcha.in_group":   "in the group",
+	"captcha.in_dm":      "privately when possible",
+
+	"announce.nothing": "nothing to announce",
+
+	"templates.line":            "%s: %s",
+	"templates.source.database": "edited with /settemplate",
+	"templates.source.config":   "from the config",
+	"templates.source.default":  "default",
+	"templates.unknown_stage":   "unknown stage, available: %s",
+	"templates.invalid":         "bad template: %v",
+	"templates.saved":           "the template for '%s' has been saved",
+	"templates.reset":           "the template for '%s' has been reset",
+
+	"listevent.none":            "No events",
+	"quiz.posted":               "Quiz for %s, %s!\n\n%s\n\n%s",
+	"quiz.split":                "all correct answers split the coins",
+	"quiz.first":                "the first %d correct answer wins|the first %d correct answers win",
+	"quiz.how_text":             "Answer by mentioning me or in a private chat within %s. Only the first answer counts.",
+	"quiz.how_choice":           "Choose the answer within %s. Only the first choice counts.",
+	"quiz.not_eligible":         "only the members of the group can answer",
+	"quiz.closed":               "the quiz is over",
+	"quiz.already_answered":     "you have already answered",
+	"quiz.wrong":                "wrong answer",
+	"quiz.correct":              "correct! the coins will be split among all correct answers when the quiz is over",
+	"quiz.won":                  "correct! you win %s, claim them in a private chat with me",
+	"quiz.no_winners":           "The quiz is over, nobody answered correctly.",
+	"quiz.results":              "The quiz is over, the winners: %s\nClaim your coins in a private chat with me.",
+	"quiz.set":                  "event %d is a quiz now",
+	"quiz.removed":              "event %d is not a quiz anymore",
+	"registration.open":         "Registration for the giveaway of %s is open until %s. Press Join to take part.",
+	"registration.open_keyword": "Registration for the giveaway of %s is open until %s. Press Join or send \"%s\" to take part.",
+	"registration.join":         "Join",
+	"registration.closed":       "the registration is closed",
+	"registration.not_eligible": "only the members of the group can register",
+	"registration.joined":       "you are registered for the giveaway",
+	"registration.already":      "you are already registered",
+	"registration.set":          "only the registered users will participate in event %d, the registration opens at %s",
+	"registration.removed":      "all enlisted users will participate in event %d",
+	"rollover.wallet":           "%s unclaimed stay in the wallet.",
+	"rollover.next":             "%s unclaimed roll over to the next event.",
+	"rollover.claimers":         "%s unclaimed go to a second round among those who claimed!",
+	"rollover.policy":           "the coins left unclaimed in event %d %s",
+	"rollover.policy_wallet":    "stay in the wallet",
+	"rollover.policy_next":      "roll over to the next event",
+	"rollover.policy_claimers":  "go to a second round among the claimers",
+	"listevent.ends_at":         "Current event ends at %s",
+	"listevent.starts_at":       "Upcoming event starts at %s",
+	"listevent.error":           "The current event has an error.",
+
+	"cancel.nothing":            "nothing to cancel",
+	"cancel.started":            "the event has already started, use /stopevent instead",
+	"cancel.done":               "event cancelled",
+	"schedule.done":             "event scheduled",
+	"edit.done":                 "event updated",
+	"schedule.active_exists":    "already have an active event",
+	"schedule.scheduled_exists": "already have an event in schedule",
+	"start.exists":              "already have an event",
+	"start.done":                "event started",
+	"stop.nothing":              "nothing to stop",
+	"stop.not_started":          "the event has not started yet, use /cancelevent instead",
+	"stop.done":                 "event stopped",
+	"winners.none":              "no winners, that's weird",
+
+	"fallback.starts_in":   "event starts in %s",
+	"fallback.not_started": "event has not started yet, come back later",
+	"fallback.no_events":   "no upcoming events, check back later",
+
+	"callback.too_old":     "the message with the button is too old",
+	"callback.not_allowed": "you are not allowed to do this",
+	"callback.failed":      "failed: %v",
+
+	"confirm.ask":            "are you sure? %s",
+	"confirm.button":         "Confirm",
+	"confirm.cancel_button":  "Cancel",
+	"confirm.done":           "confirmed: %s",
+	"confirm.cancelled_text": "cancelled: %s",
+	"confirm.cancelled":      "cancelled",
+	"confirm.expired":        "the confirmation has expired",
+	"confirm.not_yours":      "this is not your command",
+}
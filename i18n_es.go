@@ -0,0 +1,293 @@
+package skyaway
+
+var catalogEs = Catalog{
+	"unit.coins":   "%d moneda|%d monedas",
+	"unit.hours":   "%d hora|%d horas",
+	"unit.minutes": "%d minuto|%d minutos",
+	"unit.seconds": "%d segundo|%d segundos",
+
+	"tag.banned":       "bloqueado",
+	"tag.banned_until": "bloqueado hasta %s",
+	"tag.renamed":      "antes %s",
+	"tag.admin":        "admin",
+	"tag.pending":      "verificación pendiente",
+	"tag.failed":       "verificación fallida",
+	"tag.timeout":      "verificación caducada",
+
+	"field.coins":         "monedas",
+	"field.started":       "comenzó",
+	"field.will_start":    "comenzará",
+	"field.duration":      "duración",
+	"field.surprise":      "sorpresa",
+	"field.participants":  "participantes",
+	"field.claimed":       "reclamadas",
+	"field.unclaimed":     "sin reclamar",
+	"field.claimers_left": "faltan por reclamar",
+	"field.event":         "evento",
+	"field.claimers":      "reclamantes",
+	"field.first_claim":   "primer reclamo tras",
+
+	"value.time_ago":  "%s (hace %s)",
+	"value.time_in":   "%s (en %s)",
+	"value.ended_ago": "%s (terminó hace %s)",
+	"value.ends_in":   "%s (termina en %s)",
+	"value.yes":       "sí",
+	"value.no":        "no",
+
+	"title.scheduled": "¡Se ha programado un nuevo evento!",
+	"title.updated":   "El evento ha sido modificado",
+	"title.reminder":  "El evento está programado",
+	"title.started":   "¡El evento ha comenzado!",
+	"title.ongoing":   "El evento está en curso",
+	"title.ended":     "¡El evento ha terminado!",
+	"title.cancelled": "El evento programado ha sido cancelado",
+
+	"done":             "hecho",
+	"command.failed":   "el comando falló: %v",
+	"command.usage":    "uso: %s",
+	"start.greeting":   "¡Hola, este es un bot de sorteos de skycoin!\nEscribe %s para más detalles.",
+	"settings.current": "configuración actual: %s",
+
+	"language.current":     "tu idioma es %s, disponibles: %s",
+	"language.unsupported": "idioma no soportado, disponibles: %s",
+	"language.chosen":      "ahora tu idioma es %s",
+	"language.chat_chosen": "ahora el idioma del grupo es %s",
+
+	"action.created":     "creado",
+	"action.unbanned":    "desbloqueado",
+	"action.enlisted":    "añadido a la lista",
+	"action.verified":    "verificado",
+	"action.none":        "no hace falta hacer nada",
+	"adduser.not_member": "ese usuario no es miembro del grupo",
+	"admin.made":         "El usuario %s ahora es admin",
+	"admin.removed":      "El usuario %s ya no es admin",
+	"admin.mirrored":     "los admins siguen a los administradores del grupo, cámbialos en Telegram",
+	"admin.permanent":    "%s es admin permanente y no se puede quitar",
+	"unban.done":         "usuario %s desbloqueado",
+	"ban.reason":         "motivo: %s",
+	"ban.by":             "por %s",
+	"ban.left":           "quedan %s",
+	"ban.permanent":      "permanentemente",
+	"users.none":         "no hay usuarios en la lista",
+	"sync.started":       "comprobando la membresía de todos los usuarios, esto puede tardar",
+	"sync.running":       "la membresía ya se está comprobando, espera a que termine",
+	"sync.done":          "usuarios comprobados: %d, añadidos: %d, excluidos: %d, renombrados: %d, fallidos: %d, admins nuevos: %d, admins retirados: %d",
+
+	"address.saved":            "tu dirección de pago ahora es %s",
+	"address.current":          "tu dirección de pago es %s",
+	"address.none":             "no has guardado una dirección de pago, usa /setaddress",
+	"address.private":          "envíame esto en un chat privado, para que tu dirección no la vea todo el mundo",
+	"requireaddress.on":        "solo los usuarios con una dirección guardada participan en los eventos",
+	"requireaddress.off":       "los usuarios participan en los eventos sin una dirección guardada",
+	"distribution.equal":       "las monedas se reparten por igual entre los participantes",
+	"distribution.activity":    "las monedas se reparten según los mensajes en el grupo de los últimos %d días",
+	"distribution.referrals":   "las monedas se reparten según los usuarios traídos al grupo en los últimos %d días",
+	"distribution.capped":      ", como máximo %d%% para un participante",
+	"topactive.none":           "nadie escribió en el grupo en los últimos %d días",
+	"topactive.header":         "los más activos en el último %d día:|los más activos en los últimos %d días:",
+	"topactive.messages":       "%d mensaje|%d mensajes",
+	"referral.link":            "Tu enlace de invitación: %s\nHas traído a %s.",
+	"referral.count":           "%d usuario|%d usuarios",
+	"referrals.none":           "nadie ha invitado a nadie todavía",
+	"leaderboard.none":         "nadie ha reclamado nada todavía",
+	"leaderboard.coins":        "Los que más ganaron, %s:",
+	"leaderboard.claims":       "Los más constantes, %s:",
+	"leaderboard.streak":       "Las rachas más largas, %s:",
+	"leaderboard.period.week":  "última semana",
+	"leaderboard.period.month": "último mes",
+	"leaderboard.period.year":  "último año",
+	"leaderboard.period.all":   "desde siempre",
+	"leaderboard.claimed":      "%d de %d evento|%d de %d eventos",
+	"leaderboard.in_a_row":     "%d evento seguido|%d eventos seguidos",
+
+	"claim.done":         "%s reclamado a %s",
+	"claim.held":         "%s reclamado a %s, el pago queda retenido para revisión",
+	"claim.send_address": "Puedes reclamar %s, envíame tu dirección de skycoin.",
+	"claim.offer":        "Puedes reclamar %s, envíame tu dirección de skycoin o usa la guardada: %s",
+	"claim.use_saved":    "Usar la dirección guardada",
+	"claim.event_over":   "el evento ha terminado",
+
+	"reminder.claim":        "No olvides reclamar %s, el evento termina en %s. Envíame tu dirección de skycoin. Desactiva estos recordatorios con /reminders off",
+	"reminders.on":          "Te recordaré en privado que reclames antes de que terminen los eventos. Desactívalo con /reminders off",
+	"reminders.off":         "No recibes recordatorios para reclamar. Actívalos con /reminders on",
+	"reminders.unreachable": "Los recordatorios están activados, pero no puedo escribirte en privado. Inicia un chat conmigo para recibirlos.",
+
+	"status.enlisted":          "Estás en la lista de sorteos.",
+	"status.not_enlisted":      "No estás en la lista de sorteos, únete al grupo para participar.",
+	"status.banned":            "Estás bloqueado en los sorteos.",
+	"status.no_event":          "No hay ningún evento en este momento.",
+	"status.not_participating": "No participas en el evento actual, no estabas en la lista cuando empezó.",
+	"status.claimed":           "Has reclamado %s en el evento actual.",
+	"status.to_claim":          "Puedes reclamar %s en el evento actual.",
+
+	"history.line":        "#%d %s: %s, %s",
+	"history.paid":        "pagado, txid %s",
+	"history.claimed":     "reclamado",
+	"history.not_claimed": "sin reclamar",
+	"history.none":        "Todavía no has participado en ningún evento.",
+
+	"events.none":            "No hay eventos terminados",
+	"events.bad_count":       "el número de eventos debe ser de 1 a %d",
+	"events.line":            "#%d %s: %s durante %s, participantes: %d, reclamado por %d%%, sin reclamar: %s",
+	"eventstats.not_started": "el evento no ha empezado",
+	"eventstats.histogram":   "Reclamos en el tiempo:",
+
+	"suspicious.none":     "No hay direcciones de reclamo compartidas pendientes de revisión",
+	"suspicious.cluster":  "Grupo #%d: %d usuarios reclamaron a direcciones iguales o vinculadas",
+	"suspicious.more":     "%d grupos más no se muestran, bloquea o libera estos primero",
+	"suspicious.ban":      "Bloquear a %s",
+	"suspicious.release":  "Liberar a %s",
+	"suspicious.banned":   "%s está bloqueado",
+	"suspicious.released": "los pagos de %s han sido liberados",
+
+	"export.sent": "el documento se te ha enviado en privado",
+	"paid.done":   "%d pago del evento %d registrado como pagado|%d pagos del evento %d registrados como pagados",
+
+	"pager.page":      "página %d de %d",
+	"pager.prev":      "« Anterior",
+	"pager.next":      "Siguiente »",
+	"pager.expired":   "esta lista es demasiado antigua, pídela de nuevo",
+	"pager.not_yours": "esta lista la pidió otra persona",
+
+	"captcha.button":     "%s, pulsa el botón en %s para participar en los sorteos.",
+	"captcha.arithmetic": "%s, ¿cuánto es %d + %d? Responde en %s para participar en los sorteos.",
+	"captcha.press":      "No soy un bot",
+	"captcha.passed":     "%s ha sido verificado, ¡bienvenido!",
+	"captcha.failed":     "%s no ha superado la verificación.",
+	"captcha.timed_out":  "%s no ha superado la verificación a tiempo.",
+	"captcha.not_yours":  "esta verificación es para otra persona",
+	"captcha.expired":    "esta verificación ha caducado",
+	"captcha.off":        "los nuevos miembros se añaden a la lista sin verificación",
+	"captcha.on":         "los nuevos miembros reciben una verificación (%s) %s y tienen %s para superarla",
+	"captcha.in_group":   "en el grupo",
+	"captcha.in_dm":      "en privado cuando es posible",
+
+	"announce.nothing": "no hay nada que anunciar",
+
+	"templates.line":            "%s: %s",
+	"templates.source.database": "editada con /settemplate",
+	"templates.source.config":   "de la configuración",
+	"templates.source.default":  "por defecto",
+	"templates.unknown_stage":   "etapa desconocida, disponibles: %s",
+	"templates.invalid":         "plantilla incorrecta: %v",
+	"templates.saved":           "la plantilla de '%s' ha sido guardada",
+	"templates.reset":           "la plantilla de '%s' ha sido restablecida",
+
+	"listevent.none":            "No hay eventos",
+	"quiz.posted":               "¡Concurso por %s, %s!\n\n%s\n\n%s",
+	"quiz.split":                "todas las respuestas correctas se reparten las monedas",
+	"quiz.first":                "gana la primera %d respuesta correcta|ganan las primeras %d respuestas correctas",
+	"quiz.how_text":             "Responde mencionándome o en un chat privado dentro de %s. Solo cuenta la primera respuesta.",
+	"quiz.how_choice":           "Elige la respuesta dentro de %s. Solo cuenta la primera elección.",
+	"quiz.not_eligible":         "solo los miembros del grupo pueden responder",
+	"quiz.closed":               "el concurso ha terminado",
+	"quiz.already_answered":     "ya has respondido",
+	"quiz.wrong":                "respuesta incorrecta",
+	"quiz.correct":              "¡correcto! las monedas se repartirán entre todas las respuestas correctas cuando termine el concurso",
+	"quiz.won":                  "¡correcto! ganas %s, reclámalas en un chat privado conmigo",
+	"quiz.no_winners":           "El concurso ha terminado, nadie respondió correctamente.",
+	"quiz.results":              "El concurso ha terminado, los ganadores: %s\nReclamad vuestras monedas en un chat privado conmigo.",
+	"quiz.set":                  "el evento %d ahora es un concurso",
+	"quiz.removed":              "el evento %d ya no es un concurso",
+	"registration.open":         "La inscripción para el reparto de %s está abierta hasta %s. Pulsa Unirse para participar.",
+	"registration.open_keyword": "La inscripción para el reparto de %s está abierta hasta %s. Pulsa Unirse o envía \"%s\" para participar.",
+	"registration.join":         "Unirse",
+	"registration.closed":       "la inscripción está cerrada",
+	"registration.not_eligible": "solo los miembros del grupo pueden inscribirse",
+	"registration.joined":       "estás inscrito en el reparto",
+	"registration.already":      "ya estás inscrito",
+	"registration.set":          "solo los usuarios inscritos participarán en el evento %d, la inscripción se abre el %s",
+	"registration.removed":      "todos los usuarios de la lista participarán en el evento %d",
+	"rollover.wallet":           "%s sin reclamar se quedan en la billetera.",
+	"rollover.next":             "%s sin reclamar pasan al próximo evento.",
+	"rollover.claimers":         "¡%s sin reclamar van a una segunda ronda entre quienes reclamaron!",
+	"rollover.policy":           "las monedas sin reclamar del evento %d %s",
+	"rollover.policy_wallet":    "se quedan en la billetera",
+	"rollover.policy_next":      "pasan al próximo evento",
+	"rollover.policy_claimers":  "van a una segunda ronda entre quienes reclamaron",
+	"listevent.ends_at":         "El evento actual termina el %s",
+	"listevent.starts_at":       "El próximo evento comienza el %s",
+	"listevent.error":           "El evento actual tiene un error.",
+
+	"cancel.nothing":            "no hay nada que cancelar",
+	"cancel.started":            "el evento ya ha comenzado, usa /stopevent",
+	"cancel.done":               "evento cancelado",
+	"schedule.done":             "evento programado",
+	"edit.done":                 "evento actualizado",
+	"schedule.active_exists":    "ya hay un evento activo",
+	"schedule.scheduled_exists": "ya hay un evento programado",
+	"start.exists":              "ya hay un evento",
+	"start.done":                "evento iniciado",
+	"stop.nothing":              "no hay nada que detener",
+	"stop.not_started":          "el evento aún no ha comenzado, usa /cancelevent",
+	"stop.done":                 "evento detenido",
+	"winners.none":              "no hay ganadores, qué raro",
+
+	"fallback.starts_in":   "el evento comienza en %s",
+	"fallback.not_started": "el evento aún no ha comenzado, vuelve más tarde",
+	"fallback.no_events":   "no hay eventos próximos, vuelve más tarde",
+
+	"callback.too_old":     "el mensaje con el botón es demasiado antiguo",
+	"callback.not_allowed": "no tienes permiso para hacer esto",
+	"callback.failed":      "error: %v",
+
+	"confirm.ask":            "¿estás seguro? %s",
+	"confirm.button":         "Confirmar",
+	"confirm.cancel_button":  "Cancelar",
+	"confirm.done":           "confirmado: %s",
+	"confirm.cancelled_text": "cancelado: %s",
+	"confirm.cancelled":      "cancelado",
+	"confirm.expired":        "la confirmación ha caducado",
+	"confirm.not_yours":      "este no es tu comando",
+
+	"cmd.start":              "saludar al bot",
+	"cmd.help":               "este texto",
+	"cmd.language":           "ver o elegir tu idioma",
+	"cmd.chatlanguage":       "elegir el idioma del grupo",
+	"cmd.settings":           "ver la configuración del bot y del grupo",
+	"cmd.captcha":            "elegir las verificaciones que deben superar los nuevos miembros",
+	"cmd.scheduleevent":      "programar un evento en fecha ISO o legible con duración en horas",
+	"cmd.editevent":          "cambiar el evento programado con coins=, start=, duration= o surprise=yes|no",
+	"cmd.setquiz":            "convertir el evento programado en un concurso: la pregunta y las respuestas en líneas separadas, las opciones correctas marcadas con '*', 0 ganadores reparte las monedas entre todas las respuestas correctas",
+	"cmd.removequiz":         "convertir el evento programado en uno normal",
+	"cmd.setregistration":    "solo participan en el evento programado quienes se unan con el botón o la palabra clave durante la ventana antes del inicio",
+	"cmd.removeregistration": "todos los usuarios de la lista participan en el evento programado",
+	"cmd.setrollover":        "dejar las monedas sin reclamar del evento actual en la billetera, sumarlas al próximo evento o repartirlas entre quienes reclamaron en una segunda ronda",
+	"cmd.cancelevent":        "cancelar un evento programado",
+	"cmd.stopevent":          "detener el evento actual",
+	"cmd.startevent":         "iniciar un evento inmediatamente",
+	"cmd.mystatus":           "saber si participas en los sorteos y en el evento actual",
+	"cmd.history":            "listar tus participaciones en eventos pasados",
+	"cmd.setaddress":         "guardar tu dirección de pago por defecto",
+	"cmd.myaddress":          "mostrar tu dirección de pago guardada",
+	"cmd.leaderboard":        "clasificar a los miembros por monedas reclamadas, eventos reclamados o la racha más larga",
+	"cmd.reminders":          "mostrar o elegir si recibir recordatorios privados para reclamar",
+	"cmd.referral":           "obtener tu enlace personal para invitar a otros",
+	"cmd.referrals":          "los miembros que más usuarios invitaron",
+	"cmd.requireaddress":     "solo dejar participar a los usuarios con una dirección guardada",
+	"cmd.distribution":       "repartir las monedas por igual, según la actividad o según las invitaciones, limitando la parte de un participante",
+	"cmd.topactive":          "los miembros más activos del grupo",
+	"cmd.listevent":          "ver el evento actual (los admins también ven los eventos sorpresa)",
+	"cmd.adduser":            "añadir usuarios a la lista de participantes a la fuerza",
+	"cmd.makeadmin":          "hacer admin a un usuario",
+	"cmd.removeadmin":        "quitar a un usuario de admin",
+	"cmd.banuser":            "excluir a un usuario de la lista de participantes, por un tiempo si se indica",
+	"cmd.unbanuser":          "quitar a un usuario de la lista negra",
+	"cmd.announce":           "enviar un anuncio",
+	"cmd.announceevent":      "forzar el anuncio del evento actual o programado",
+	"cmd.usercount":          "número de usuarios",
+	"cmd.syncusers":          "comprobar en Telegram la membresía de todos los usuarios",
+	"cmd.users":              "todos los usuarios de la lista, opciones: prefix=, enlisted, admins, sort=name|id",
+	"cmd.bannedusers":        "todos los usuarios bloqueados, opciones: prefix=, enlisted, admins, sort=name|id",
+	"cmd.listwinners":        "lista de ganadores de un evento, opciones: prefix=, enlisted, admins, sort=coins|name|id",
+	"cmd.events":             "listar los últimos eventos terminados con su tasa de reclamo",
+	"cmd.eventstats":         "mostrar las estadísticas de reclamos de un evento",
+	"cmd.suspicious":         "listar los usuarios con pagos retenidos que reclaman a direcciones iguales o vinculadas",
+	"cmd.paid":               "registrar la transacción que pagó los reclamos de un evento, de los usuarios o de todos",
+	"cmd.exportwinners":      "enviar en privado los participantes de un evento como documento csv o json",
+	"cmd.exportusers":        "enviar en privado todos los usuarios como documento csv o json",
+	"cmd.templates":          "listar las plantillas de anuncios y su origen",
+	"cmd.settemplate":        "cambiar la plantilla de anuncio de una etapa del evento",
+	"cmd.resettemplate":      "restaurar la plantilla de anuncio configurada",
+	"cmd.previewtemplate":    "ver en privado un anuncio con el evento actual o uno de ejemplo",
+}
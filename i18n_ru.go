@@ -0,0 +1,293 @@
+package skyaway
+
+var catalogRu = Catalog{
+	"unit.coins":   "%d монета|%d монеты|%d монет",
+	"unit.hours":   "%d час|%d часа|%d часов",
+	"unit.minutes": "%d минута|%d минуты|%d минут",
+	"unit.seconds": "%d секунда|%d секунды|%d секунд",
+
+	"tag.banned":       "заблокирован",
+	"tag.banned_until": "заблокирован до %s",
+	"tag.renamed":      "ранее %s",
+	"tag.admin":        "админ",
+	"tag.pending":      "ожидает проверки",
+	"tag.failed":       "не прошёл проверку",
+	"tag.timeout":      "не прошёл проверку вовремя",
+
+	"field.coins":         "монеты",
+	"field.started":       "начало",
+	"field.will_start":    "начнётся",
+	"field.duration":      "длительность",
+	"field.surprise":      "сюрприз",
+	"field.participants":  "участники",
+	"field.claimed":       "получено",
+	"field.unclaimed":     "не получено",
+	"field.claimers_left": "осталось получателей",
+	"field.event":         "раздача",
+	"field.claimers":      "получатели",
+	"field.first_claim":   "первое получение через",
+
+	"value.time_ago":  "%s (%s назад)",
+	"value.time_in":   "%s (через %s)",
+	"value.ended_ago": "%s (закончилось %s назад)",
+	"value.ends_in":   "%s (закончится через %s)",
+	"value.yes":       "да",
+	"value.no":        "нет",
+
+	"title.scheduled": "Запланирована новая раздача!",
+	"title.updated":   "Раздача изменена",
+	"title.reminder":  "Раздача запланирована",
+	"title.started":   "Раздача началась!",
+	"title.ongoing":   "Раздача идёт",
+	"title.ended":     "Раздача закончилась!",
+	"title.cancelled": "Запланированная раздача отменена",
+
+	"done":             "готово",
+	"command.failed":   "команда не выполнена: %v",
+	"command.usage":    "использование: %s",
+	"start.greeting":   "Привет, это бот раздачи skycoin!\nНаберите %s, чтобы узнать больше.",
+	"settings.current": "текущие настройки: %s",
+
+	"language.current":     "ваш язык: %s, доступны: %s",
+	"language.unsupported": "язык не поддерживается, доступны: %s",
+	"language.chosen":      "теперь ваш язык: %s",
+	"language.chat_chosen": "теперь язык группы: %s",
+
+	"action.created":     "создан",
+	"action.unbanned":    "разблокирован",
+	"action.enlisted":    "добавлен в список",
+	"action.verified":    "проверен",
+	"action.none":        "ничего делать не нужно",
+	"adduser.not_member": "этот пользователь не состоит в группе",
+	"admin.made":         "Пользователь %s теперь админ",
+	"admin.removed":      "Пользователь %s больше не админ",
+	"admin.mirrored":     "админы совпадают с администраторами группы, меняйте их в Telegram",
+	"admin.permanent":    "%s — постоянный админ, его нельзя снять",
+	"unban.done":         "пользователь %s разблокирован",
+	"ban.reason":         "причина: %s",
+	"ban.by":             "заблокировал %s",
+	"ban.left":           "осталось %s",
+	"ban.permanent":      "навсегда",
+	"users.none":         "в списке нет пользователей",
+	"sync.started":       "проверяю членство всех пользователей, это может занять время",
+	"sync.running":       "членство пользователей уже проверяется, дождитесь окончания",
+	"sync.done":          "проверено пользователей: %d, добавлено: %d, исключено: %d, переименовано: %d, ошибок: %d, назначено админов: %d, снято: %d",
+
+	"address.saved":            "ваш адрес для выплат теперь %s",
+	"address.current":          "ваш адрес для выплат: %s",
+	"address.none":             "вы не сохранили адрес для выплат, используйте /setaddress",
+	"address.private":          "отправьте это мне в личные сообщения, чтобы ваш адрес не видели все",
+	"requireaddress.on":        "в раздачах участвуют только пользователи с сохранённым адресом",
+	"requireaddress.off":       "в раздачах участвуют и пользователи без сохранённого адреса",
+	"distribution.equal":       "монеты делятся между участниками поровну",
+	"distribution.activity":    "монеты делятся по числу сообщений в группе за последние %d дн.",
+	"distribution.referrals":   "монеты делятся по числу пользователей, приглашённых в группу за последние %d дн.",
+	"distribution.capped":      ", одному участнику не больше %d%%",
+	"topactive.none":           "за последние %d дн. в группе никто не писал",
+	"topactive.header":         "самые активные за последний %d день:|самые активные за последние %d дня:|самые активные за последние %d дней:",
+	"topactive.messages":       "%d сообщение|%d сообщения|%d сообщений",
+	"referral.link":            "Ваша пригласительная ссылка: %s\nВы пригласили %s.",
+	"referral.count":           "%d пользователя|%d пользователей|%d пользователей",
+	"referrals.none":           "пока никто никого не пригласил",
+	"leaderboard.none":         "пока никто ничего не получил",
+	"leaderboard.coins":        "Больше всего получили, %s:",
+	"leaderboard.claims":       "Самые постоянные участники, %s:",
+	"leaderboard.streak":       "Самые длинные серии, %s:",
+	"leaderboard.period.week":  "за неделю",
+	"leaderboard.period.month": "за месяц",
+	"leaderboard.period.year":  "за год",
+	"leaderboard.period.all":   "за всё время",
+	"leaderboard.claimed":      "%d из %d раздачи|%d из %d раздач|%d из %d раздач",
+	"leaderboard.in_a_row":     "%d раздача подряд|%d раздачи подряд|%d раздач подряд",
+
+	"claim.done":         "%s отправлено на %s",
+	"claim.held":         "%s отправлено на %s, выплата задержана для проверки",
+	"claim.send_address": "Вы можете получить %s, пришлите мне свой адрес skycoin.",
+	"claim.offer":        "Вы можете получить %s, пришлите мне свой адрес skycoin или используйте сохранённый: %s",
+	"claim.use_saved":    "Использовать сохранённый адрес",
+	"claim.event_over":   "раздача закончилась",
+
+	"reminder.claim":        "Не забудьте получить %s, раздача закончится через %s. Пришлите мне свой адрес skycoin. Отключить эти напоминания: /reminders off",
+	"reminders.on":          "Я напомню вам в личных сообщениях получить монеты до конца раздачи. Отключить: /reminders off",
+	"reminders.off":         "Вы не получаете напоминаний. Включить: /reminders on",
+	"reminders.unreachable": "Напоминания включены, но я не могу написать вам в личные сообщения. Начните чат со мной, чтобы получать их.",
+
+	"status.enlisted":          "Вы в списке участников раздач.",
+	"status.not_enlisted":      "Вас нет в списке участников, вступите в группу, чтобы участвовать.",
+	"status.banned":            "Вы заблокированы в раздачах.",
+	"status.no_event":          "Сейчас нет раздачи.",
+	"status.not_participating": "Вы не участвуете в текущей раздаче, вас не было в списке, когда она началась.",
+	"status.claimed":           "Вы получили %s в текущей раздаче.",
+	"status.to_claim":          "Вы можете получить %s в текущей раздаче.",
+
+	"history.line":        "#%d %s: %s, %s",
+	"history.paid":        "выплачено, txid %s",
+	"history.claimed":     "получено",
+	"history.not_claimed": "не получено",
+	"history.none":        "Вы ещё не участвовали в раздачах.",
+
+	"events.none":            "Нет завершённых раздач",
+	"events.bad_count":       "количество раздач должно быть от 1 до %d",
+	"events.line":            "#%d %s: %s на %s, участники: %d, получили %d%%, не получено: %s",
+	"eventstats.not_started": "раздача ещё не началась",
+	"eventstats.histogram":   "Получения по времени:",
+
+	"suspicious.none":     "Нет общих адресов получения, ожидающих проверки",
+	"suspicious.cluster":  "Группа #%d: %d пользователей получали на одинаковые или связанные адреса",
+	"suspicious.more":     "ещё %d групп не показано, сначала заблокируйте или отпустите эти",
+	"suspicious.ban":      "Заблокировать %s",
+	"suspicious.release":  "Отпустить %s",
+	"suspicious.banned":   "%s заблокирован",
+	"suspicious.released": "выплаты %s отпущены",
+
+	"export.sent": "документ отправлен вам в личные сообщения",
+	"paid.done":   "%d выплата в раздаче %d отмечена оплаченной|%d выплаты в раздаче %d отмечены оплаченными|%d выплат в раздаче %d отмечены оплаченными",
+
+	"pager.page":      "страница %d из %d",
+	"pager.prev":      "« Назад",
+	"pager.next":      "Далее »",
+	"pager.expired":   "этот список устарел, запросите его снова",
+	"pager.not_yours": "этот список запросил кто-то другой",
+
+	"captcha.button":     "%s, нажмите кнопку в течение %s, чтобы участвовать в раздачах.",
+	"captcha.arithmetic": "%s, сколько будет %d + %d? Ответьте в течение %s, чтобы участвовать в раздачах.",
+	"captcha.press":      "Я не бот",
+	"captcha.passed":     "%s прошёл проверку, добро пожаловать!",
+	"captcha.failed":     "%s не прошёл проверку.",
+	"captcha.timed_out":  "%s не прошёл проверку вовремя.",
+	"captcha.not_yours":  "эта проверка для другого пользователя",
+	"captcha.expired":    "эта проверка устарела",
+	"captcha.off":        "новые участники добавляются в список без проверки",
+	"captcha.on":         "новые участники проходят проверку (%s) %s, на неё даётся %s",
+	"captcha.in_group":   "в группе",
+	"captcha.in_dm":      "в личных сообщениях, если возможно",
+
+	"announce.nothing": "нечего объявлять",
+
+	"templates.line":            "%s: %s",
+	"templates.source.database": "изменён через /settemplate",
+	"templates.source.config":   "из конфигурации",
+	"templates.source.default":  "по умолчанию",
+	"templates.unknown_stage":   "неизвестный этап, доступны: %s",
+	"templates.invalid":         "ошибка в шаблоне: %v",
+	"templates.saved":           "шаблон для '%s' сохранён",
+	"templates.reset":           "шаблон для '%s' сброшен",
+
+	"listevent.none":            "Раздач нет",
+	"quiz.posted":               "Викторина на %s, %s!\n\n%s\n\n%s",
+	"quiz.split":                "монеты делят все ответившие правильно",
+	"quiz.first":                "выигрывает первый %d правильный ответ|выигрывают первые %d правильных ответа|выигрывают первые %d правильных ответов",
+	"quiz.how_text":             "Отвечайте, упомянув меня, или в личном чате в течение %s. Засчитывается только первый ответ.",
+	"quiz.how_choice":           "Выберите ответ в течение %s. Засчитывается только первый выбор.",
+	"quiz.not_eligible":         "отвечать могут только участники группы",
+	"quiz.closed":               "викторина окончена",
+	"quiz.already_answered":     "вы уже ответили",
+	"quiz.wrong":                "неверный ответ",
+	"quiz.correct":              "верно! монеты будут разделены между всеми верными ответами, когда викторина закончится",
+	"quiz.won":                  "верно! вы выиграли %s, получите их в личном чате со мной",
+	"quiz.no_winners":           "Викторина окончена, никто не ответил правильно.",
+	"quiz.results":              "Викторина окончена, победители: %s\nПолучите монеты в личном чате со мной.",
+	"quiz.set":                  "раздача %d теперь викторина",
+	"quiz.removed":              "раздача %d больше не викторина",
+	"registration.open":         "Регистрация на раздачу %s открыта до %s. Нажмите «Участвовать», чтобы принять участие.",
+	"registration.open_keyword": "Регистрация на раздачу %s открыта до %s. Нажмите «Участвовать» или отправьте «%s», чтобы принять участие.",
+	"registration.join":         "Участвовать",
+	"registration.closed":       "регистрация закрыта",
+	"registration.not_eligible": "зарегистрироваться могут только участники группы",
+	"registration.joined":       "вы зарегистрированы на раздачу",
+	"registration.already":      "вы уже зарегистрированы",
+	"registration.set":          "в раздаче %d будут участвовать только зарегистрированные, регистрация откроется %s",
+	"registration.removed":      "в раздаче %d будут участвовать все пользователи из списка",
+	"rollover.wallet":           "%s остались невостребованными и вернулись в кошелёк.",
+	"rollover.next":             "%s остались невостребованными и перейдут в следующую раздачу.",
+	"rollover.claimers":         "%s остались невостребованными и разыгрываются во втором раунде среди получивших!",
+	"rollover.policy":           "монеты, не полученные в раздаче %d, %s",
+	"rollover.policy_wallet":    "остаются в кошельке",
+	"rollover.policy_next":      "переходят в следующую раздачу",
+	"rollover.policy_claimers":  "разыгрываются во втором раунде среди получивших",
+	"listevent.ends_at":         "Текущая раздача закончится %s",
+	"listevent.starts_at":       "Следующая раздача начнётся %s",
+	"listevent.error":           "С текущей раздачей что-то не так.",
+
+	"cancel.nothing":            "нечего отменять",
+	"cancel.started":            "раздача уже началась, используйте /stopevent",
+	"cancel.done":               "раздача отменена",
+	"schedule.done":             "раздача запланирована",
+	"edit.done":                 "раздача изменена",
+	"schedule.active_exists":    "уже идёт раздача",
+	"schedule.scheduled_exists": "раздача уже запланирована",
+	"start.exists":              "раздача уже есть",
+	"start.done":                "раздача начата",
+	"stop.nothing":              "нечего останавливать",
+	"stop.not_started":          "раздача ещё не началась, используйте /cancelevent",
+	"stop.done":                 "раздача остановлена",
+	"winners.none":              "победителей нет, странно",
+
+	"fallback.starts_in":   "раздача начнётся через %s",
+	"fallback.not_started": "раздача ещё не началась, загляните позже",
+	"fallback.no_events":   "раздач не ожидается, загляните позже",
+
+	"callback.too_old":     "сообщение с кнопкой слишком старое",
+	"callback.not_allowed": "вам нельзя это делать",
+	"callback.failed":      "ошибка: %v",
+
+	"confirm.ask":            "вы уверены? %s",
+	"confirm.button":         "Подтвердить",
+	"confirm.cancel_button":  "Отмена",
+	"confirm.done":           "подтверждено: %s",
+	"confirm.cancelled_text": "отменено: %s",
+	"confirm.cancelled":      "отменено",
+	"confirm.expired":        "время подтверждения истекло",
+	"confirm.not_yours":      "это не ваша команда",
+
+	"cmd.start":              "поздороваться с ботом",
+	"cmd.help":               "этот текст",
+	"cmd.language":           "показать или выбрать ваш язык",
+	"cmd.chatlanguage":       "выбрать язык группы",
+	"cmd.settings":           "показать настройки бота и группы",
+	"cmd.captcha":            "выбрать проверки для новых участников группы",
+	"cmd.scheduleevent":      "запланировать раздачу на время в ISO или в свободной форме с длительностью в часах",
+	"cmd.editevent":          "изменить запланированную раздачу: coins=, start=, duration= или surprise=yes|no",
+	"cmd.setquiz":            "сделать запланированную раздачу викториной: вопрос и ответы на отдельных строках, верные варианты отмечаются '*', 0 победителей — монеты делятся между всеми верными ответами",
+	"cmd.removequiz":         "сделать запланированную раздачу обычной",
+	"cmd.setregistration":    "в запланированной раздаче участвуют только нажавшие кнопку или отправившие ключевое слово в окно регистрации перед началом",
+	"cmd.removeregistration": "в запланированной раздаче участвуют все пользователи из списка",
+	"cmd.setrollover":        "оставить невостребованные монеты текущей раздачи в кошельке, добавить их к следующей раздаче или разделить между получившими во втором раунде",
+	"cmd.cancelevent":        "отменить запланированную раздачу",
+	"cmd.stopevent":          "остановить текущую раздачу",
+	"cmd.startevent":         "начать раздачу немедленно",
+	"cmd.mystatus":           "узнать, участвуете ли вы в раздачах и в текущей раздаче",
+	"cmd.history":            "ваше участие в прошлых раздачах",
+	"cmd.setaddress":         "сохранить адрес для выплат",
+	"cmd.myaddress":          "показать сохранённый адрес для выплат",
+	"cmd.leaderboard":        "рейтинг участников по полученным монетам, числу раздач или самой длинной серии",
+	"cmd.reminders":          "показать или выбрать, получать ли напоминания в личных сообщениях",
+	"cmd.referral":           "получить личную ссылку для приглашения",
+	"cmd.referrals":          "участники, пригласившие больше всего пользователей",
+	"cmd.requireaddress":     "участвовать в раздачах могут только пользователи с сохранённым адресом",
+	"cmd.distribution":       "делить монеты поровну, по активности или по приглашениям, ограничивая долю одного участника",
+	"cmd.topactive":          "самые активные участники группы",
+	"cmd.listevent":          "показать текущую раздачу (админы видят и сюрпризы)",
+	"cmd.adduser":            "принудительно добавить пользователей в список участников",
+	"cmd.makeadmin":          "сделать пользователя админом",
+	"cmd.removeadmin":        "снять с пользователя права админа",
+	"cmd.banuser":            "исключить пользователя из списка участников, на время, если оно указано",
+	"cmd.unbanuser":          "вернуть пользователя в список участников",
+	"cmd.announce":           "отправить объявление",
+	"cmd.announceevent":      "принудительно объявить текущую или запланированную раздачу",
+	"cmd.usercount":          "количество пользователей",
+	"cmd.syncusers":          "проверить членство всех пользователей в Telegram",
+	"cmd.users":              "все пользователи из списка, параметры: prefix=, enlisted, admins, sort=name|id",
+	"cmd.bannedusers":        "все заблокированные пользователи, параметры: prefix=, enlisted, admins, sort=name|id",
+	"cmd.listwinners":        "список победителей раздачи, параметры: prefix=, enlisted, admins, sort=coins|name|id",
+	"cmd.events":             "последние завершённые раздачи и доля получивших",
+	"cmd.eventstats":         "статистика получений в раздаче",
+	"cmd.suspicious":         "пользователи с задержанными выплатами, получающие на одинаковые или связанные адреса",
+	"cmd.paid":               "записать транзакцию, оплатившую получения в раздаче, для указанных пользователей или для всех",
+	"cmd.exportwinners":      "участники раздачи в виде документа csv или json в личные сообщения",
+	"cmd.exportusers":        "все пользователи в виде документа csv или json в личные сообщения",
+	"cmd.templates":          "список шаблонов объявлений и их источников",
+	"cmd.settemplate":        "изменить шаблон объявления для этапа раздачи",
+	"cmd.resettemplate":      "вернуть шаблон объявления из конфигурации",
+	"cmd.previewtemplate":    "посмотреть объявление на текущей или примерной раздаче",
+}
@@ -0,0 +1,124 @@
+package skyaway
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var verbRegexp = regexp.MustCompile(`%[a-z]`)
+
+// Matches the message keys used in the source: tr.T("key", ...) and
+// tr.N("key", ...).
+var keyRegexp = regexp.MustCompile(`\.[TN]\(\s*"([a-z_.]+)"`)
+
+// The number of plural forms each language is expected to have.
+var pluralForms = map[string]int{
+	"en": 2,
+	"es": 2,
+	"ru": 3,
+	"zh": 1,
+}
+
+func TestCatalogsHaveAllKeys(t *testing.T) {
+	for lang, catalog := range catalogs {
+		if lang == defaultLanguage {
+			continue
+		}
+		for key, format := range catalogEn {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing key %q", lang, key)
+				continue
+			}
+			// plural forms are compared by the first one
+			translated, format = strings.Split(translated, "|")[0], strings.Split(format, "|")[0]
+			if got, want := verbRegexp.FindAllString(translated, -1), verbRegexp.FindAllString(format, -1); strings.Join(got, "") != strings.Join(want, "") {
+				t.Errorf("%s: key %q has verbs %v, want %v", lang, key, got, want)
+			}
+		}
+		for key := range catalog {
+			if _, ok := catalogEn[key]; !ok && !strings.HasPrefix(key, "cmd.") {
+				t.Errorf("%s: unknown key %q", lang, key)
+			}
+		}
+		for _, command := range commands {
+			if _, ok := catalog["cmd."+command.Command]; !ok {
+				t.Errorf("%s: missing description of /%s", lang, command.Command)
+			}
+		}
+	}
+}
+
+func TestSourceKeysInCatalog(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range keyRegexp.FindAllStringSubmatch(string(src), -1) {
+			key := match[1]
+			if strings.HasSuffix(key, ".") {
+				// a prefix of a composed key
+				continue
+			}
+			if _, ok := catalogEn[key]; !ok {
+				t.Errorf("%s: key %q is not in the catalog", file, key)
+			}
+		}
+	}
+}
+
+func TestStageTitlesInCatalog(t *testing.T) {
+	for _, stage := range stages {
+		if _, ok := catalogEn["title."+stage]; !ok {
+			t.Errorf("missing title for stage %q", stage)
+		}
+	}
+}
+
+func TestCatalogsPluralForms(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, format := range catalog {
+			if !strings.HasPrefix(key, "unit.") {
+				continue
+			}
+			if n := len(strings.Split(format, "|")); n != pluralForms[lang] {
+				t.Errorf("%s: key %q has %d plural forms, want %d", lang, key, n, pluralForms[lang])
+			}
+		}
+	}
+}
+
+func TestPluralForms(t *testing.T) {
+	ru := Translator{"ru"}
+	for n, want := range map[int]string{
+		1:  "1 монета",
+		2:  "2 монеты",
+		5:  "5 монет",
+		11: "11 монет",
+		21: "21 монета",
+		24: "24 монеты",
+	} {
+		if got := ru.Coins(n); got != want {
+			t.Errorf("ru.Coins(%d) = %q, want %q", n, got, want)
+		}
+	}
+
+	en := Translator{"en"}
+	if got, want := en.Coins(1), "1 coin"; got != want {
+		t.Errorf("en.Coins(1) = %q, want %q", got, want)
+	}
+	if got, want := en.Coins(3), "3 coins"; got != want {
+		t.Errorf("en.Coins(3) = %q, want %q", got, want)
+	}
+}
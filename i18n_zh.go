@@ -0,0 +1,293 @@
+package skyaway
+
+var catalogZh = Catalog{
+	"unit.coins":   "%d 个币",
+	"unit.hours":   "%d 小时",
+	"unit.minutes": "%d 分钟",
+	"unit.seconds": "%d 秒",
+
+	"tag.banned":       "已封禁",
+	"tag.banned_until": "封禁至 %s",
+	"tag.renamed":      "曾用名 %s",
+	"tag.admin":        "管理员",
+	"tag.pending":      "待验证",
+	"tag.failed":       "验证失败",
+	"tag.timeout":      "验证超时",
+
+	"field.coins":         "币数",
+	"field.started":       "开始时间",
+	"field.will_start":    "将于",
+	"field.duration":      "时长",
+	"field.surprise":      "惊喜活动",
+	"field.participants":  "参与人数",
+	"field.claimed":       "已领取",
+	"field.unclaimed":     "未领取",
+	"field.claimers_left": "剩余领取人数",
+	"field.event":         "活动",
+	"field.claimers":      "领取人数",
+	"field.first_claim":   "首次领取于",
+
+	"value.time_ago":  "%s（%s前）",
+	"value.time_in":   "%s（%s后）",
+	"value.ended_ago": "%s（%s前结束）",
+	"value.ends_in":   "%s（%s后结束）",
+	"value.yes":       "是",
+	"value.no":        "否",
+
+	"title.scheduled": "新的活动已安排！",
+	"title.updated":   "活动已更改",
+	"title.reminder":  "活动已安排",
+	"title.started":   "活动开始了！",
+	"title.ongoing":   "活动进行中",
+	"title.ended":     "活动结束了！",
+	"title.cancelled": "已安排的活动已取消",
+
+	"done":             "完成",
+	"command.failed":   "命令失败：%v",
+	"command.usage":    "用法：%s",
+	"start.greeting":   "你好，这是 skycoin 赠币机器人！\n输入 %s 查看详情。",
+	"settings.current": "当前设置：%s",
+
+	"language.current":     "你的语言是 %s，可选：%s",
+	"language.unsupported": "不支持该语言，可选：%s",
+	"language.chosen":      "你的语言已设为 %s",
+	"language.chat_chosen": "群组语言已设为 %s",
+
+	"action.created":     "已创建",
+	"action.unbanned":    "已解封",
+	"action.enlisted":    "已加入名单",
+	"action.verified":    "已验证",
+	"action.none":        "无需操作",
+	"adduser.not_member": "该用户不是群成员",
+	"admin.made":         "用户 %s 现在是管理员",
+	"admin.removed":      "用户 %s 不再是管理员",
+	"admin.mirrored":     "管理员与群组管理员保持一致，请在 Telegram 中修改",
+	"admin.permanent":    "%s 是永久管理员，无法移除",
+	"unban.done":         "已解封用户 %s",
+	"ban.reason":         "原因：%s",
+	"ban.by":             "操作者：%s",
+	"ban.left":           "剩余 %s",
+	"ban.permanent":      "永久",
+	"users.none":         "名单中没有用户",
+	"sync.started":       "正在检查所有用户的成员身份，可能需要一些时间",
+	"sync.running":       "正在检查成员身份，请等待完成",
+	"sync.done":          "已检查用户：%d，加入：%d，移出：%d，改名：%d，失败：%d，新增管理员：%d，移除管理员：%d",
+
+	"address.saved":            "你的收款地址现在是 %s",
+	"address.current":          "你的收款地址是 %s",
+	"address.none":             "你还没有保存收款地址，请使用 /setaddress",
+	"address.private":          "请在私聊中发给我，以免你的地址被所有人看到",
+	"requireaddress.on":        "只有保存了地址的用户才能参加活动",
+	"requireaddress.off":       "没有保存地址的用户也能参加活动",
+	"distribution.equal":       "代币在参与者之间平均分配",
+	"distribution.activity":    "代币按最近 %d 天内的群组消息数分配",
+	"distribution.referrals":   "代币按最近 %d 天内邀请进群的用户数分配",
+	"distribution.capped":      "，每位参与者最多 %d%%",
+	"topactive.none":           "最近 %d 天内没有人在群组发言",
+	"topactive.header":         "最近 %d 天最活跃的成员：",
+	"topactive.messages":       "%d 条消息",
+	"referral.link":            "你的邀请链接：%s\n你已邀请 %s。",
+	"referral.count":           "%d 位用户",
+	"referrals.none":           "还没有人邀请过别人",
+	"leaderboard.none":         "还没有人领取过代币",
+	"leaderboard.coins":        "领取最多，%s：",
+	"leaderboard.claims":       "最常领取，%s：",
+	"leaderboard.streak":       "最长连续领取，%s：",
+	"leaderboard.period.week":  "最近一周",
+	"leaderboard.period.month": "最近一个月",
+	"leaderboard.period.year":  "最近一年",
+	"leaderboard.period.all":   "全部时间",
+	"leaderboard.claimed":      "%d / %d 次活动",
+	"leaderboard.in_a_row":     "连续 %d 次活动",
+
+	"claim.done":         "%s 已领取到 %s",
+	"claim.held":         "%s 已领取到 %s，付款暂缓等待审核",
+	"claim.send_address": "你可以领取 %s，请发送你的 skycoin 地址。",
+	"claim.offer":        "你可以领取 %s，请发送你的 skycoin 地址或使用已保存的地址：%s",
+	"claim.use_saved":    "使用已保存的地址",
+	"claim.event_over":   "活动已结束",
+
+	"reminder.claim":        "别忘了领取 %s，活动将在 %s 后结束。请把你的 skycoin 地址发给我。使用 /reminders off 关闭这些提醒",
+	"reminders.on":          "我会在活动结束前私信提醒你领取。使用 /reminders off 关闭",
+	"reminders.off":         "你不会收到领取提醒。使用 /reminders on 开启",
+	"reminders.unreachable": "领取提醒已开启，但我无法私信你。请先与我开始聊天以接收提醒。",
+
+	"status.enlisted":          "你在赠送名单中。",
+	"status.not_enlisted":      "你不在赠送名单中，加入群组即可参与。",
+	"status.banned":            "你已被禁止参加赠送。",
+	"status.no_event":          "目前没有活动。",
+	"status.not_participating": "你没有参加当前活动，活动开始时你不在名单中。",
+	"status.claimed":           "你已在当前活动中领取 %s。",
+	"status.to_claim":          "你可以在当前活动中领取 %s。",
+
+	"history.line":        "#%d %s：%s，%s",
+	"history.paid":        "已支付，txid %s",
+	"history.claimed":     "已领取",
+	"history.not_claimed": "未领取",
+	"history.none":        "你还没有参加过任何活动。",
+
+	"events.none":            "没有已结束的活动",
+	"events.bad_count":       "活动数量应在 1 到 %d 之间",
+	"events.line":            "#%d %s：%s，持续 %s，参与者：%d，领取率 %d%%，未领取：%s",
+	"eventstats.not_started": "活动尚未开始",
+	"eventstats.histogram":   "领取时间分布：",
+
+	"suspicious.none":     "没有待审核的共用领取地址",
+	"suspicious.cluster":  "第 %d 组：%d 个用户领取到相同或关联的地址",
+	"suspicious.more":     "还有 %d 组未显示，请先封禁或放行这些",
+	"suspicious.ban":      "封禁 %s",
+	"suspicious.release":  "放行 %s",
+	"suspicious.banned":   "%s 已被封禁",
+	"suspicious.released": "%s 的付款已放行",
+
+	"export.sent": "文件已私下发送给你",
+	"paid.done":   "%d 笔付款（活动 %d）已记录为已支付",
+
+	"pager.page":      "第 %d 页，共 %d 页",
+	"pager.prev":      "« 上一页",
+	"pager.next":      "下一页 »",
+	"pager.expired":   "此列表已过期，请重新请求",
+	"pager.not_yours": "此列表是其他人请求的",
+
+	"captcha.button":     "%s，请在 %s 内按下按钮以参加赠送。",
+	"captcha.arithmetic": "%s，%d + %d 等于多少？请在 %s 内回答以参加赠送。",
+	"captcha.press":      "我不是机器人",
+	"captcha.passed":     "%s 已通过验证，欢迎！",
+	"captcha.failed":     "%s 未通过验证。",
+	"captcha.timed_out":  "%s 未能及时通过验证。",
+	"captcha.not_yours":  "此验证是给其他人的",
+	"captcha.expired":    "此验证已过期",
+	"captcha.off":        "新成员无需验证即加入名单",
+	"captcha.on":         "新成员需通过验证（%s）%s，时限为 %s",
+	"captcha.in_group":   "在群组中",
+	"captcha.in_dm":      "尽可能私下进行",
+
+	"announce.nothing": "没有可公告的内容",
+
+	"templates.line":            "%s: %s",
+	"templates.source.database": "通过 /settemplate 编辑",
+	"templates.source.config":   "来自配置",
+	"templates.source.default":  "默认",
+	"templates.unknown_stage":   "未知阶段，可用：%s",
+	"templates.invalid":         "模板错误：%v",
+	"templates.saved":           "'%s' 的模板已保存",
+	"templates.reset":           "'%s' 的模板已重置",
+
+	"listevent.none":            "没有活动",
+	"quiz.posted":               "有奖问答：%s，%s！\n\n%s\n\n%s",
+	"quiz.split":                "所有答对的人平分代币",
+	"quiz.first":                "前 %d 个正确答案获胜",
+	"quiz.how_text":             "请在 %s 内提及我或私聊我作答。只计算第一次回答。",
+	"quiz.how_choice":           "请在 %s 内选择答案。只计算第一次选择。",
+	"quiz.not_eligible":         "只有群组成员可以作答",
+	"quiz.closed":               "问答已结束",
+	"quiz.already_answered":     "你已经回答过了",
+	"quiz.wrong":                "回答错误",
+	"quiz.correct":              "正确！问答结束后代币将在所有正确答案之间平分",
+	"quiz.won":                  "正确！你赢得了 %s，请私聊我领取",
+	"quiz.no_winners":           "问答已结束，没有人答对。",
+	"quiz.results":              "问答已结束，获胜者：%s\n请私聊我领取代币。",
+	"quiz.set":                  "活动 %d 现在是有奖问答",
+	"quiz.removed":              "活动 %d 不再是有奖问答",
+	"registration.open":         "%s 的发放活动开放报名，截止 %s。点击“参加”即可参与。",
+	"registration.open_keyword": "%s 的发放活动开放报名，截止 %s。点击“参加”或发送“%s”即可参与。",
+	"registration.join":         "参加",
+	"registration.closed":       "报名已结束",
+	"registration.not_eligible": "只有群组成员可以报名",
+	"registration.joined":       "你已报名参加本次发放",
+	"registration.already":      "你已经报名了",
+	"registration.set":          "活动 %d 仅限报名用户参与，报名于 %s 开放",
+	"registration.removed":      "活动 %d 将由名单中的所有用户参与",
+	"rollover.wallet":           "未领取的 %s 留在钱包中。",
+	"rollover.next":             "未领取的 %s 将转入下一次活动。",
+	"rollover.claimers":         "未领取的 %s 将在已领取者之间进行第二轮分配！",
+	"rollover.policy":           "活动 %d 中未领取的币%s",
+	"rollover.policy_wallet":    "留在钱包中",
+	"rollover.policy_next":      "转入下一次活动",
+	"rollover.policy_claimers":  "在已领取者之间进行第二轮分配",
+	"listevent.ends_at":         "当前活动结束于 %s",
+	"listevent.starts_at":       "下一个活动开始于 %s",
+	"listevent.error":           "当前活动出错了。",
+
+	"cancel.nothing":            "没有可取消的活动",
+	"cancel.started":            "活动已经开始，请使用 /stopevent",
+	"cancel.done":               "活动已取消",
+	"schedule.done":             "活动已安排",
+	"edit.done":                 "活动已更新",
+	"schedule.active_exists":    "已有进行中的活动",
+	"schedule.scheduled_exists": "已有安排好的活动",
+	"start.exists":              "已有活动",
+	"start.done":                "活动已开始",
+	"stop.nothing":              "没有可停止的活动",
+	"stop.not_started":          "活动尚未开始，请使用 /cancelevent",
+	"stop.done":                 "活动已停止",
+	"winners.none":              "没有获奖者，真奇怪",
+
+	"fallback.starts_in":   "活动将在 %s后开始",
+	"fallback.not_started": "活动尚未开始，请稍后再来",
+	"fallback.no_events":   "近期没有活动，请稍后再来",
+
+	"callback.too_old":     "带按钮的消息太旧了",
+	"callback.not_allowed": "你无权执行此操作",
+	"callback.failed":      "失败：%v",
+
+	"confirm.ask":            "确定吗？%s",
+	"confirm.button":         "确认",
+	"confirm.cancel_button":  "取消",
+	"confirm.done":           "已确认：%s",
+	"confirm.cancelled_text": "已取消：%s",
+	"confirm.cancelled":      "已取消",
+	"confirm.expired":        "确认已过期",
+	"confirm.not_yours":      "这不是你的命令",
+
+	"cmd.start":              "向机器人打招呼",
+	"cmd.help":               "显示本帮助",
+	"cmd.language":           "查看或选择你的语言",
+	"cmd.chatlanguage":       "选择群组语言",
+	"cmd.settings":           "查看机器人和群组设置",
+	"cmd.captcha":            "选择新成员需要通过的验证",
+	"cmd.scheduleevent":      "按 ISO 时间或自然语言时间安排活动，时长以小时计",
+	"cmd.editevent":          "修改已安排的活动：coins=、start=、duration= 或 surprise=yes|no",
+	"cmd.setquiz":            "将已安排的活动设为有奖问答：问题和答案分行填写，正确选项用 '*' 标记，获胜人数为 0 时所有答对的人平分代币",
+	"cmd.removequiz":         "将已安排的活动恢复为普通活动",
+	"cmd.setregistration":    "已安排的活动仅由在开始前的报名时段内点击按钮或发送关键词的用户参与",
+	"cmd.removeregistration": "已安排的活动由名单中的所有用户参与",
+	"cmd.setrollover":        "将当前活动未领取的币留在钱包中、加入下一次活动或在第二轮中分给已领取者",
+	"cmd.cancelevent":        "取消已安排的活动",
+	"cmd.stopevent":          "停止当前活动",
+	"cmd.startevent":         "立即开始活动",
+	"cmd.mystatus":           "查看你是否参加赠送以及当前活动",
+	"cmd.history":            "列出你参加过的活动",
+	"cmd.setaddress":         "保存你的默认收款地址",
+	"cmd.myaddress":          "显示你保存的收款地址",
+	"cmd.leaderboard":        "按领取的代币、领取次数或最长连续领取对成员排名",
+	"cmd.reminders":          "查看或选择是否接收领取的私信提醒",
+	"cmd.referral":           "获取你的专属邀请链接",
+	"cmd.referrals":          "邀请用户最多的成员",
+	"cmd.requireaddress":     "只允许保存了地址的用户参加活动",
+	"cmd.distribution":       "平均分配代币、按活跃度或按邀请数分配，并限制单个参与者的份额",
+	"cmd.topactive":          "群组中最活跃的成员",
+	"cmd.listevent":          "查看当前活动（管理员也能看到惊喜活动）",
+	"cmd.adduser":            "强制将用户加入参与名单",
+	"cmd.makeadmin":          "设为管理员",
+	"cmd.removeadmin":        "取消管理员",
+	"cmd.banuser":            "将用户移出参与名单，可指定期限",
+	"cmd.unbanuser":          "将用户移出黑名单",
+	"cmd.announce":           "发送公告",
+	"cmd.announceevent":      "强制公告当前或已安排的活动",
+	"cmd.usercount":          "用户数量",
+	"cmd.syncusers":          "向 Telegram 核对所有用户的成员身份",
+	"cmd.users":              "名单中的所有用户，选项：prefix=, enlisted, admins, sort=name|id",
+	"cmd.bannedusers":        "所有被封禁的用户，选项：prefix=, enlisted, admins, sort=name|id",
+	"cmd.listwinners":        "活动获奖者名单，选项：prefix=, enlisted, admins, sort=coins|name|id",
+	"cmd.events":             "列出最近结束的活动及其领取率",
+	"cmd.eventstats":         "显示活动的领取统计",
+	"cmd.suspicious":         "列出付款被暂扣且领取到相同或关联地址的用户",
+	"cmd.paid":               "记录支付活动领取的交易，针对指定用户或所有人",
+	"cmd.exportwinners":      "以 csv 或 json 文件私下发送活动参与者",
+	"cmd.exportusers":        "以 csv 或 json 文件私下发送所有用户",
+	"cmd.templates":          "列出公告模板及其来源",
+	"cmd.settemplate":        "修改某个活动阶段的公告模板",
+	"cmd.resettemplate":      "恢复配置中的公告模板",
+	"cmd.previewtemplate":    "用当前活动或示例活动私下预览公告",
+}
@@ -0,0 +1,169 @@
+package skyaway
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// What the leaderboards rank the users by.
+const (
+	LeaderboardCoins  = "coins"  // the coins claimed
+	LeaderboardClaims = "claims" // the number of events claimed
+	LeaderboardStreak = "streak" // the most events claimed in a row
+)
+
+// The periods the leaderboards cover, by the start of the events.
+var leaderboardPeriods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+const (
+	leaderboardListed   = 10
+	leaderboardCacheTTL = 10 * time.Minute
+	leaderboardCooldown = time.Minute // between the leaderboards in the group
+)
+
+type leaderboardEntry struct {
+	UserID   int
+	UserName string
+	Score    int
+	Of       int // the number of participations, for the claims
+}
+
+type leaderboardCache struct {
+	mu          sync.Mutex
+	boards      map[string][]leaderboardEntry
+	builtAt     map[string]time.Time
+	lastInGroup time.Time
+}
+
+func newLeaderboardCache() *leaderboardCache {
+	return &leaderboardCache{
+		boards:  make(map[string][]leaderboardEntry),
+		builtAt: make(map[string]time.Time),
+	}
+}
+
+func (c *leaderboardCache) get(key string) ([]leaderboardEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.builtAt[key]) > leaderboardCacheTTL {
+		return nil, false
+	}
+	board, ok := c.boards[key]
+	return board, ok
+}
+
+func (c *leaderboardCache) put(key string, board []leaderboardEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.boards[key] = board
+	c.builtAt[key] = time.Now()
+}
+
+// Forgets the leaderboards, e.g. when an event ends.
+func (c *leaderboardCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.boards = make(map[string][]leaderboardEntry)
+	c.builtAt = make(map[string]time.Time)
+}
+
+// Returns false if a leaderboard was shown in the group less than the
+// cooldown ago, and starts the cooldown otherwise.
+func (c *leaderboardCache) allowInGroup() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.lastInGroup) < leaderboardCooldown {
+		return false
+	}
+	c.lastInGroup = time.Now()
+	return true
+}
+
+// Ranks the users by the participations in the events, which are listed in
+// the order they started.
+func rankClaims(kind string, events []int, records []ClaimRecord) []leaderboardEntry {
+	entries := make(map[int]*leaderboardEntry)
+	claimed := make(map[int]map[int]bool)
+	for _, r := range records {
+		e, ok := entries[r.UserID]
+		if !ok {
+			e = &leaderboardEntry{UserID: r.UserID, UserName: r.UserName}
+			entries[r.UserID] = e
+			claimed[r.UserID] = make(map[int]bool)
+		}
+		e.Of++
+		if !r.Claimed {
+			continue
+		}
+		claimed[r.UserID][r.EventID] = true
+		switch kind {
+		case LeaderboardCoins:
+			e.Score += r.Coins
+		case LeaderboardClaims:
+			e.Score++
+		}
+	}
+
+	if kind == LeaderboardStreak {
+		for id, e := range entries {
+			streak := 0
+			for _, event := range events {
+				if claimed[id][event] {
+					streak++
+				} else {
+					streak = 0
+				}
+				if streak > e.Score {
+					e.Score = streak
+				}
+			}
+		}
+	}
+
+	var board []leaderboardEntry
+	for _, e := range entries {
+		if e.Score > 0 {
+			board = append(board, *e)
+		}
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Score != board[j].Score {
+			return board[i].Score > board[j].Score
+		}
+		if board[i].Of != board[j].Of {
+			return board[i].Of < board[j].Of
+		}
+		return board[i].UserID < board[j].UserID
+	})
+	if len(board) > leaderboardListed {
+		board = board[:leaderboardListed]
+	}
+	return board
+}
+
+// Returns the leaderboard of the kind over the period, from the cache if it
+// is fresh.
+func (bot *Bot) leaderboard(kind, period string) ([]leaderboardEntry, error) {
+	key := kind + ":" + period
+	if board, ok := bot.leaderboards.get(key); ok {
+		return board, nil
+	}
+
+	var since time.Time
+	if d := leaderboardPeriods[period]; d > 0 {
+		since = time.Now().Add(-d)
+	}
+	events, records, err := bot.db.GetClaimHistory(since)
+	if err != nil {
+		return nil, err
+	}
+	board := rankClaims(kind, events, records)
+	bot.leaderboards.put(key, board)
+	return board, nil
+}
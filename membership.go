@@ -0,0 +1,149 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// How often the membership is synced with telegram unless configured with
+// `sync_interval`.
+const defaultSyncInterval = 6 * time.Hour
+
+// What a membership sync has changed.
+type SyncReport struct {
+	Checked  int
+	Enlisted int
+	Delisted int
+	Renamed  int
+	Failed   int
+	Promoted int
+	Demoted  int
+}
+
+func (r SyncReport) String() string {
+	return fmt.Sprintf(
+		"%d checked, %d enlisted, %d delisted, %d renamed, %d failed, %d promoted, %d demoted",
+		r.Checked, r.Enlisted, r.Delisted, r.Renamed, r.Failed, r.Promoted, r.Demoted,
+	)
+}
+
+func (bot *Bot) syncInterval() time.Duration {
+	if bot.config.SyncInterval.Valid && bot.config.SyncInterval.Duration > 0 {
+		return bot.config.SyncInterval.Duration
+	}
+	return defaultSyncInterval
+}
+
+var SyncRunning = errors.New("the membership is being synced already")
+
+// Tells whether the user is in the chat. Restricted users may be in the chat
+// or not, and this version of the api does not tell which, so `known` is
+// false for them.
+func chatMembership(member tgbotapi.ChatMember) (in bool, known bool) {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true, true
+	case "restricted":
+		return false, false
+	}
+	return false, true
+}
+
+// Asks telegram about every known user and fixes their names and whether
+// they are enlisted. Users who have not passed the verification are not
+// enlisted. The admins are mirrored too if configured. Only one sync runs at
+// a time, SyncRunning is returned if another one has not finished yet.
+func (bot *Bot) syncUsers() (SyncReport, error) {
+	if !atomic.CompareAndSwapInt32(&bot.syncing, 0, 1) {
+		return SyncReport{}, SyncRunning
+	}
+	defer atomic.StoreInt32(&bot.syncing, 0)
+	return bot.doSyncUsers()
+}
+
+func (bot *Bot) doSyncUsers() (SyncReport, error) {
+	var report SyncReport
+	var err error
+	report.Promoted, report.Demoted, err = bot.mirrorAdmins()
+	if err != nil {
+		return report, err
+	}
+
+	users, err := bot.db.GetAllUsers()
+	if err != nil {
+		return report, fmt.Errorf("failed to get users from db: %v", err)
+	}
+
+	for _, listed := range users {
+		report.Checked++
+
+		var member tgbotapi.ChatMember
+		err := bot.call(0, PriorityChatter, func() (err error) {
+			member, err = bot.telegram.GetChatMember(tgbotapi.ChatConfigWithUser{
+				ChatID: bot.config.ChatID,
+				UserID: listed.ID,
+			})
+			return err
+		})
+		if err != nil {
+			log.Printf("failed to get the membership of %s: %v", listed.NameAndTags(), err)
+			report.Failed++
+			continue
+		}
+
+		// the user may have changed while waiting for telegram
+		u := bot.db.GetUser(listed.ID)
+		if u == nil {
+			continue
+		}
+
+		if m := member.User; m != nil && (m.UserName != u.UserName || m.FirstName != u.FirstName || m.LastName != u.LastName) {
+			if err := bot.db.RenameUser(u, m.UserName, m.FirstName, m.LastName); err != nil {
+				return report, err
+			}
+			report.Renamed++
+		}
+
+		changed := false
+		verified := u.Verification == VerificationNone || u.Verification == VerificationPassed
+		// the restricted users are left as they are
+		if in, known := chatMembership(member); known {
+			if in && !u.Enlisted && verified {
+				u.Enlisted = true
+				report.Enlisted++
+				changed = true
+			} else if !in && u.Enlisted {
+				u.Enlisted = false
+				report.Delisted++
+				changed = true
+			}
+		}
+
+		if changed {
+			if err := bot.db.PutUser(u); err != nil {
+				return report, fmt.Errorf("failed to save the user: %v", err)
+			}
+			bot.recordReferral(u)
+		}
+	}
+
+	log.Printf("membership synced: %s", report)
+	return report, nil
+}
+
+// Syncs the membership at startup and then on the interval.
+func (bot *Bot) syncMembership() {
+	for {
+		if _, err := bot.syncUsers(); err == SyncRunning {
+			log.Printf("skipping the membership sync: %v", err)
+		} else if err != nil {
+			log.Printf("failed to sync the membership: %v", err)
+		}
+		time.Sleep(bot.syncInterval())
+	}
+}
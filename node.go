@@ -0,0 +1,74 @@
+package skyaway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// How long to wait for the skycoin node.
+const nodeTimeout = 10 * time.Second
+
+var nodeClient = &http.Client{Timeout: nodeTimeout}
+
+// A transaction as returned by the node's /api/v1/transactions?verbose=1.
+type nodeTransaction struct {
+	Txn struct {
+		Inputs []struct {
+			Owner string `json:"owner"`
+		} `json:"inputs"`
+	} `json:"txn"`
+}
+
+// Asks the node configured as `wallet.rpc` for the addresses which spent
+// coins together with the address in the same transactions, i.e. which are
+// likely to belong to the same wallet. Returns nothing if no node is
+// configured.
+func (bot *Bot) linkedAddresses(address string) ([]string, error) {
+	if bot.config.Wallet.RPC == "" {
+		return nil, nil
+	}
+
+	query := url.Values{
+		"addrs":   {address},
+		"verbose": {"1"},
+	}
+	resp, err := nodeClient.Get(strings.TrimSuffix(bot.config.Wallet.RPC, "/") + "/api/v1/transactions?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the node: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the node responded with %s", resp.Status)
+	}
+
+	var txns []nodeTransaction
+	if err := json.NewDecoder(resp.Body).Decode(&txns); err != nil {
+		return nil, fmt.Errorf("failed to decode the node response: %v", err)
+	}
+
+	seen := map[string]bool{address: true}
+	var linked []string
+	for _, txn := range txns {
+		spent := false
+		for _, input := range txn.Txn.Inputs {
+			if input.Owner == address {
+				spent = true
+				break
+			}
+		}
+		if !spent {
+			continue
+		}
+		for _, input := range txn.Txn.Inputs {
+			if !seen[input.Owner] {
+				seen[input.Owner] = true
+				linked = append(linked, input.Owner)
+			}
+		}
+	}
+	return linked, nil
+}
@@ -0,0 +1,229 @@
+package skyaway
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// The priorities of outgoing requests. Announcements go before chatter.
+const (
+	PriorityChatter = iota
+	PriorityAnnouncement
+	priorities
+)
+
+// Telegram allows about 30 messages per second overall, one message per
+// second in a private chat and 20 messages per minute in a group.
+const (
+	globalSendInterval  = time.Second / 30
+	privateSendInterval = time.Second
+	groupSendInterval   = time.Minute / 20
+)
+
+// How many times a request is retried after hitting the flood limit.
+const floodRetries = 3
+
+// Telegram reports the flood limit as "Too Many Requests: retry after 35".
+var retryAfterRegexp = regexp.MustCompile(`retry after (\d+)`)
+
+type sendResult struct {
+	message tgbotapi.Message
+	err     error
+}
+
+type outgoing struct {
+	chatID   int64 // zero for requests not bound to a chat
+	priority int
+	do       func() (tgbotapi.Message, error)
+	retries  int
+	result   chan sendResult               // nil if nobody waits for the result
+	done     func(tgbotapi.Message, error) // called with the result instead, if set
+}
+
+// A queue of outgoing telegram requests which keeps within the telegram rate
+// limits. Requests are sent one by one from a single goroutine.
+type outbox struct {
+	mu         sync.Mutex
+	queues     [priorities][]*outgoing
+	nextGlobal time.Time
+	nextInChat map[int64]time.Time
+	wake       chan struct{}
+}
+
+func newOutbox() *outbox {
+	return &outbox{
+		nextInChat: make(map[int64]time.Time),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func chatSendInterval(chatID int64) time.Duration {
+	switch {
+	case chatID == 0:
+		return 0
+	case chatID < 0:
+		return groupSendInterval
+	default:
+		return privateSendInterval
+	}
+}
+
+// Returns the delay requested by telegram if the error is a flood limit.
+func retryAfter(err error) time.Duration {
+	if err == nil {
+		return 0
+	}
+	match := retryAfterRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	seconds, _ := strconv.Atoi(match[1])
+	return time.Duration(seconds) * time.Second
+}
+
+// Whether telegram refused to deliver the message to the user, which happens
+// if the user has never started a private chat with the bot or has blocked
+// it.
+func isForbidden(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "Forbidden")
+}
+
+func (o *outbox) enqueue(out *outgoing) {
+	o.mu.Lock()
+	o.queues[out.priority] = append(o.queues[out.priority], out)
+	o.mu.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Queues the request and waits until it is sent.
+func (o *outbox) send(chatID int64, priority int, do func() (tgbotapi.Message, error)) (tgbotapi.Message, error) {
+	out := &outgoing{
+		chatID:   chatID,
+		priority: priority,
+		do:       do,
+		result:   make(chan sendResult, 1),
+	}
+	o.enqueue(out)
+
+	result := <-out.result
+	return result.message, result.err
+}
+
+// Queues the request without waiting for it, so that the caller is not held
+// by the rate limits. The result is passed to `done` in its own goroutine if
+// given, otherwise the errors are only logged.
+func (o *outbox) post(chatID int64, priority int, do func() (tgbotapi.Message, error), done func(tgbotapi.Message, error)) {
+	o.enqueue(&outgoing{
+		chatID:   chatID,
+		priority: priority,
+		do:       do,
+		done:     done,
+	})
+}
+
+// Returns the number of queued requests by priority.
+func (o *outbox) Len() [priorities]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var n [priorities]int
+	for priority, queue := range o.queues {
+		n[priority] = len(queue)
+	}
+	return n
+}
+
+// Takes the first request which may be sent now, trying higher priorities
+// first. Returns nil and the time to wait if nothing can be sent yet.
+func (o *outbox) next(now time.Time) (*outgoing, time.Duration) {
+	if now.Before(o.nextGlobal) {
+		return nil, o.nextGlobal.Sub(now)
+	}
+
+	for chatID, next := range o.nextInChat {
+		if !now.Before(next) {
+			delete(o.nextInChat, chatID)
+		}
+	}
+
+	wait := time.Hour
+	for priority := priorities - 1; priority >= 0; priority-- {
+		queue := o.queues[priority]
+		for i, out := range queue {
+			next, busy := o.nextInChat[out.chatID]
+			if busy {
+				if d := next.Sub(now); d < wait {
+					wait = d
+				}
+				continue
+			}
+
+			o.queues[priority] = append(queue[:i:i], queue[i+1:]...)
+			o.nextGlobal = now.Add(globalSendInterval)
+			if interval := chatSendInterval(out.chatID); interval > 0 {
+				o.nextInChat[out.chatID] = now.Add(interval)
+			}
+			return out, 0
+		}
+	}
+	return nil, wait
+}
+
+// Puts the request back to the head of its queue and holds the chat for the
+// requested time.
+func (o *outbox) retry(out *outgoing, delay time.Duration) {
+	out.retries++
+	o.queues[out.priority] = append([]*outgoing{out}, o.queues[out.priority]...)
+	until := time.Now().Add(delay)
+	if out.chatID == 0 {
+		o.nextGlobal = until
+	} else {
+		o.nextInChat[out.chatID] = until
+	}
+}
+
+func (o *outbox) run() {
+	for {
+		o.mu.Lock()
+		out, wait := o.next(time.Now())
+		o.mu.Unlock()
+
+		if out == nil {
+			select {
+			case <-o.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		message, err := out.do()
+		if delay := retryAfter(err); delay > 0 && out.retries < floodRetries {
+			log.Printf("flood limit hit in chat %d, retrying in %v", out.chatID, delay)
+			o.mu.Lock()
+			o.retry(out, delay)
+			o.mu.Unlock()
+			continue
+		}
+		if out.done != nil {
+			go out.done(message, err)
+			continue
+		}
+		if out.result == nil {
+			if err != nil {
+				log.Printf("failed to send to chat %d: %v", out.chatID, err)
+			}
+			continue
+		}
+		out.result <- sendResult{message, err}
+	}
+}
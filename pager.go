@@ -0,0 +1,179 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// Limits of a single page of a long list. Telegram does not accept messages
+// longer than 4096 characters.
+const (
+	pageLines = 30
+	pageChars = 3500
+)
+
+// How long the pages of a list can be flipped.
+const pagerTimeout = time.Hour
+
+// A long list sent as one message which is edited to show other pages.
+type pager struct {
+	userID  int
+	pages   []string
+	expires time.Time
+}
+
+// Splits the lines into pages of at most `pageLines` lines and `pageChars`
+// characters.
+func paginate(lines []string) []string {
+	var pages []string
+	var page []string
+	var chars int
+	for _, line := range lines {
+		if len(page) > 0 && (len(page) == pageLines || chars+len(line) > pageChars) {
+			pages = append(pages, strings.Join(page, "\n"))
+			page, chars = nil, 0
+		}
+		page = append(page, line)
+		chars += len(line) + 1
+	}
+	if len(page) > 0 {
+		pages = append(pages, strings.Join(page, "\n"))
+	}
+	return pages
+}
+
+// Parses the list options of the form "prefix=ab", "enlisted", "admins" and
+// "sort=key", where the key is one of `orders`.
+func parseListFilter(options []string, orders map[string]string, defaultSort string) (ListFilter, error) {
+	filter := ListFilter{Sort: defaultSort}
+	for _, option := range options {
+		name, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			name, value = option[:i], option[i+1:]
+		}
+		switch name {
+		case "prefix":
+			filter.Prefix = strings.TrimPrefix(value, "@")
+		case "enlisted":
+			filter.Enlisted = true
+		case "admins":
+			filter.Admins = true
+		case "sort":
+			if _, ok := orders[value]; !ok {
+				var keys []string
+				for key := range orders {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				return filter, fmt.Errorf("unknown sort order '%s', expected one of: %s", value, strings.Join(keys, ", "))
+			}
+			filter.Sort = value
+		default:
+			return filter, fmt.Errorf("unknown list option: %s", option)
+		}
+	}
+	return filter, nil
+}
+
+func (p *pager) text(tr Translator, page int) string {
+	return fmt.Sprintf("%s\n\n%s", p.pages[page], tr.T("pager.page", page+1, len(p.pages)))
+}
+
+func (bot *Bot) pagerMarkup(tr Translator, token string, page, count int) (*tgbotapi.InlineKeyboardMarkup, error) {
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		prev, err := bot.CallbackButton(tr.T("pager.prev"), "page", fmt.Sprintf("%s:%d", token, page-1))
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, prev)
+	}
+	if page < count-1 {
+		next, err := bot.CallbackButton(tr.T("pager.next"), "page", fmt.Sprintf("%s:%d", token, page+1))
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, next)
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &markup, nil
+}
+
+// Replies with the lines, splitting them into pages flipped with inline
+// buttons if they do not fit into one message.
+func (bot *Bot) ReplyPaged(ctx *Context, lines []string) error {
+	pages := paginate(lines)
+	if len(pages) < 2 {
+		return bot.Reply(ctx, strings.Join(lines, "\n"))
+	}
+
+	now := time.Now()
+	for token, p := range bot.pagers {
+		if now.After(p.expires) {
+			delete(bot.pagers, token)
+		}
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	p := &pager{
+		userID:  ctx.User.ID,
+		pages:   pages,
+		expires: now.Add(pagerTimeout),
+	}
+
+	tr := bot.Tr(ctx)
+	markup, err := bot.pagerMarkup(tr, token, 0, len(pages))
+	if err != nil {
+		return err
+	}
+	msg := tgbotapi.NewMessage(ctx.message.Chat.ID, p.text(tr, 0))
+	msg.ReplyToMessageID = ctx.message.MessageID
+	msg.ReplyMarkup = markup
+	bot.post(msg.ChatID, PriorityChatter, msg)
+
+	bot.pagers[token] = p
+	return nil
+}
+
+func (bot *Bot) handleCallbackPage(ctx *Context, payload string) (string, error) {
+	tr := bot.Tr(ctx)
+	i := strings.LastIndex(payload, ":")
+	if i < 0 {
+		return "", BadCallbackData
+	}
+	token := payload[:i]
+	page, err := strconv.Atoi(payload[i+1:])
+	if err != nil {
+		return "", BadCallbackData
+	}
+
+	p, found := bot.pagers[token]
+	if !found || time.Now().After(p.expires) {
+		delete(bot.pagers, token)
+		return "", errors.New(tr.T("pager.expired"))
+	}
+	if p.userID != ctx.User.ID {
+		return "", errors.New(tr.T("pager.not_yours"))
+	}
+	if page < 0 || page >= len(p.pages) {
+		return "", BadCallbackData
+	}
+
+	markup, err := bot.pagerMarkup(tr, token, page, len(p.pages))
+	if err != nil {
+		return "", err
+	}
+	edit := tgbotapi.NewEditMessageText(ctx.message.Chat.ID, ctx.message.MessageID, p.text(tr, page))
+	edit.ReplyMarkup = markup
+	bot.post(ctx.message.Chat.ID, PriorityChatter, edit)
+	return "", nil
+}
@@ -0,0 +1,111 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// Formats the live claim statistics of a started event.
+func (bot *Bot) formatEventStatsAsMarkdown(tr Translator, event *Event) (string, error) {
+	if !event.StartedAt.Valid {
+		return "", nil
+	}
+
+	participants, err := bot.db.ParticipantCount(event)
+	if err != nil {
+		return "", err
+	}
+	claimed, err := bot.db.CoinsClaimed(event)
+	if err != nil {
+		return "", err
+	}
+	claimers, err := bot.db.ClaimersLeft(event)
+	if err != nil {
+		return "", err
+	}
+
+	var fields []string
+	fields = appendField(fields, tr.T("field.participants"), "%d", participants)
+	fields = appendField(fields, tr.T("field.claimed"), "%s", tr.Coins(claimed))
+	fields = appendField(fields, tr.T("field.unclaimed"), "%s", tr.Coins(event.Coins-claimed))
+	fields = appendField(fields, tr.T("field.claimers_left"), "%d", claimers)
+	return strings.Join(fields, "\n"), nil
+}
+
+func (bot *Bot) postPinnedEvent(event *Event, md string) error {
+	msg := tgbotapi.NewMessage(bot.config.ChatID, md)
+	msg.ParseMode = "Markdown"
+	sent, err := bot.send(bot.config.ChatID, PriorityAnnouncement, msg)
+	if err != nil {
+		return fmt.Errorf("failed to post the event message: %v", err)
+	}
+
+	if err := bot.db.SetEventMessage(event, sent.MessageID); err != nil {
+		return fmt.Errorf("failed to save the event message id: %v", err)
+	}
+
+	err = bot.call(bot.config.ChatID, PriorityAnnouncement, func() error {
+		_, err := bot.telegram.PinChatMessage(tgbotapi.PinChatMessageConfig{
+			ChatID:              bot.config.ChatID,
+			MessageID:           sent.MessageID,
+			DisableNotification: true,
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pin the event message: %v", err)
+	}
+	return nil
+}
+
+func (bot *Bot) unpinEvent(event *Event) error {
+	return bot.call(bot.config.ChatID, PriorityAnnouncement, func() error {
+		_, err := bot.telegram.MakeRequest("unpinChatMessage", url.Values{
+			"chat_id":    {strconv.FormatInt(bot.config.ChatID, 10)},
+			"message_id": {strconv.FormatInt(event.MessageID.Int64, 10)},
+		})
+		return err
+	})
+}
+
+// Edits the pinned event message in place, posting and pinning it first if
+// the event does not have one yet. The message gets unpinned when the event
+// is over.
+func (bot *Bot) UpdatePinnedEvent(event *Event, stage string) error {
+	md, err := bot.renderAnnouncement(event, stage, true)
+	if err != nil {
+		return fmt.Errorf("failed to format the event: %v", err)
+	}
+
+	if !event.MessageID.Valid {
+		if event.EndedAt.Valid {
+			// never pinned, so just tell how it ended
+			return bot.Send(&Context{}, "yell", "markdown", md)
+		}
+		return bot.postPinnedEvent(event, md)
+	}
+
+	edit := tgbotapi.NewEditMessageText(bot.config.ChatID, int(event.MessageID.Int64), md)
+	edit.ParseMode = "Markdown"
+	if _, err := bot.send(bot.config.ChatID, PriorityAnnouncement, edit); err != nil {
+		if strings.Contains(err.Error(), "message is not modified") {
+			return nil
+		}
+		log.Printf("failed to edit the event message, posting a new one: %v", err)
+		if err := bot.postPinnedEvent(event, md); err != nil {
+			return err
+		}
+	}
+
+	if event.EndedAt.Valid {
+		if err := bot.unpinEvent(event); err != nil {
+			return fmt.Errorf("failed to unpin the event message: %v", err)
+		}
+	}
+	return nil
+}
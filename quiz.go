@@ -0,0 +1,283 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// How the answers to a quiz are checked.
+const (
+	QuizExact  = "exact"  // equal to one of the answers, ignoring the case and extra spaces
+	QuizRegex  = "regex"  // matches one of the answers as a whole, ignoring the case
+	QuizChoice = "choice" // one of the options marked as correct, chosen with a button
+)
+
+// How often the quizzes are checked for the end of answering.
+const quizCheckInterval = 10 * time.Second
+
+func normalizeAnswer(answer string) string {
+	return strings.Join(strings.Fields(answer), " ")
+}
+
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Parses the question and the answers, one per line, in the text given to
+// /setquiz. The correct options of a choice quiz are marked with '*'.
+func parseQuiz(match, text string) (*Quiz, error) {
+	lines := splitLines(text)
+	if len(lines) < 2 {
+		return nil, errors.New("expected the question and the answers on separate lines")
+	}
+	q := &Quiz{Question: lines[0], Match: match}
+
+	var answers, options []string
+	switch match {
+	case QuizExact:
+		answers = lines[1:]
+	case QuizRegex:
+		for _, pattern := range lines[1:] {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("malformed answer pattern: %v", err)
+			}
+		}
+		answers = lines[1:]
+	case QuizChoice:
+		for _, line := range lines[1:] {
+			option := strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			if strings.HasPrefix(line, "*") {
+				answers = append(answers, option)
+			}
+			options = append(options, option)
+		}
+		if len(options) < 2 {
+			return nil, errors.New("expected at least two options")
+		}
+		if len(answers) == 0 {
+			return nil, errors.New("expected the correct options marked with '*'")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported quiz: %s, expected exact, regex or choice", match)
+	}
+
+	q.Answers = strings.Join(answers, "\n")
+	q.Options = strings.Join(options, "\n")
+	return q, nil
+}
+
+// Whether the answer is correct.
+func (q *Quiz) Check(answer string) bool {
+	answer = normalizeAnswer(answer)
+	for _, correct := range splitLines(q.Answers) {
+		switch q.Match {
+		case QuizExact:
+			if strings.EqualFold(answer, normalizeAnswer(correct)) {
+				return true
+			}
+		case QuizRegex:
+			matched, err := regexp.MatchString("(?i)^(?:"+correct+")$", answer)
+			if err == nil && matched {
+				return true
+			}
+		case QuizChoice:
+			if answer == correct {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Posts the question of the quiz to the group, with the options as buttons
+// for a choice quiz.
+func (bot *Bot) postQuiz(event *Event, q *Quiz) error {
+	tr := bot.groupTr()
+	rule := tr.T("quiz.split")
+	if q.Winners > 0 {
+		rule = tr.N("quiz.first", q.Winners)
+	}
+	how := tr.T("quiz.how_text", tr.Duration(q.AnswerFor.Duration))
+	if q.Match == QuizChoice {
+		how = tr.T("quiz.how_choice", tr.Duration(q.AnswerFor.Duration))
+	}
+
+	msg := tgbotapi.NewMessage(bot.config.ChatID, tr.T("quiz.posted", tr.Coins(event.Coins), rule, q.Question, how))
+	if q.Match == QuizChoice {
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for i, option := range splitLines(q.Options) {
+			button, err := bot.CallbackButton(option, "quiz", fmt.Sprintf("%d:%d", event.ID, i))
+			if err != nil {
+				return err
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	sent, err := bot.send(msg.ChatID, PriorityAnnouncement, msg)
+	if err != nil {
+		return fmt.Errorf("failed to post the quiz: %v", err)
+	}
+	return bot.db.SetQuizMessage(q, sent.MessageID)
+}
+
+// Returns the started event and its quiz if it accepts answers.
+func (bot *Bot) openQuiz() (*Event, *Quiz) {
+	event := bot.db.GetCurrentEvent()
+	if event == nil || !event.StartedAt.Valid {
+		return nil, nil
+	}
+	q := bot.db.GetQuiz(event.ID)
+	if q == nil || q.Closed {
+		return nil, nil
+	}
+	return event, q
+}
+
+// Checks the answer of the user and tells the outcome.
+func (bot *Bot) answerQuiz(ctx *Context, event *Event, q *Quiz, answer string) (string, error) {
+	tr := bot.Tr(ctx)
+	u := ctx.User
+	if u.Banned || !u.Enlisted || (bot.eligibility().RequireAddress && u.Address == "") {
+		return tr.T("quiz.not_eligible"), nil
+	}
+
+	rank, closed, err := bot.db.AnswerQuiz(q, event, u, answer, q.Check(answer))
+	switch err {
+	case nil:
+	case QuizClosed:
+		return tr.T("quiz.closed"), nil
+	case AlreadyAnswered:
+		return tr.T("quiz.already_answered"), nil
+	default:
+		return "", err
+	}
+	log.Printf("quiz answer of %s: %q, correct: %t", u.NameAndTags(), answer, rank > 0)
+
+	if closed {
+		defer func() {
+			if err := bot.announceQuizResults(event); err != nil {
+				log.Printf("failed to announce the quiz results: %v", err)
+			}
+		}()
+	}
+
+	switch {
+	case rank == 0:
+		return tr.T("quiz.wrong"), nil
+	case q.Winners == 0:
+		return tr.T("quiz.correct"), nil
+	}
+	p, err := bot.db.GetParticipant(u, event)
+	if err != nil || p == nil {
+		return "", fmt.Errorf("failed to get the participant: %v", err)
+	}
+	return tr.T("quiz.won", tr.Coins(p.Coins)), nil
+}
+
+// Handles the answers to the quiz sent privately or mentioning the bot in
+// the group. Payout addresses and anything sent while no quiz is open go to
+// the other handlers.
+func (bot *Bot) handleQuizMessage(ctx *Context, text string) (bool, error) {
+	answer := strings.TrimSpace(text)
+	if answer == "" || validateAddress(answer) == nil {
+		return true, nil
+	}
+	event, q := bot.openQuiz()
+	if q == nil || q.Match == QuizChoice {
+		return true, nil
+	}
+
+	reply, err := bot.answerQuiz(ctx, event, q, answer)
+	if err != nil {
+		return false, err
+	}
+	return false, bot.Reply(ctx, reply)
+}
+
+func (bot *Bot) handleCallbackQuiz(ctx *Context, payload string) (string, error) {
+	tr := bot.Tr(ctx)
+	parts := strings.SplitN(payload, ":", 2)
+	if len(parts) != 2 {
+		return "", BadCallbackData
+	}
+	eventID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return "", BadCallbackData
+	}
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", BadCallbackData
+	}
+
+	event, q := bot.openQuiz()
+	if q == nil || event.ID != eventID {
+		return "", errors.New(tr.T("quiz.closed"))
+	}
+	options := splitLines(q.Options)
+	if index < 0 || index >= len(options) {
+		return "", BadCallbackData
+	}
+	return bot.answerQuiz(ctx, event, q, options[index])
+}
+
+// Tells the group who won the quiz.
+func (bot *Bot) announceQuizResults(event *Event) error {
+	tr := bot.groupTr()
+	winners, err := bot.db.GetWinners(event.ID, ListFilter{Sort: "coins"})
+	if err != nil {
+		return fmt.Errorf("failed to get winners from db: %v", err)
+	}
+
+	text := tr.T("quiz.no_winners")
+	if len(winners) > 0 {
+		var names []string
+		for _, p := range winners {
+			name := strconv.Itoa(p.UserID)
+			if p.UserName != "" {
+				name = "@" + p.UserName
+			}
+			names = append(names, name)
+		}
+		text = tr.T("quiz.results", strings.Join(names, ", "))
+	}
+	return bot.Send(&Context{}, "yell", "text", text)
+}
+
+// Stops accepting answers to the quizzes when their time is up and
+// allocates the coins.
+func (bot *Bot) closeQuizzes() {
+	for range time.Tick(quizCheckInterval) {
+		event, q := bot.openQuiz()
+		if q == nil || time.Since(event.StartedAt.Time) < q.AnswerFor.Duration {
+			continue
+		}
+
+		closed, err := bot.db.CloseQuiz(q, event)
+		if err != nil {
+			log.Printf("failed to close the quiz: %v", err)
+			continue
+		}
+		if !closed {
+			continue
+		}
+		log.Printf("closed the quiz of event %d", event.ID)
+		if err := bot.announceQuizResults(event); err != nil {
+			log.Printf("failed to announce the quiz results: %v", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// The /start payload of the referral links is "ref_" and the code.
+const referralPrefix = "ref_"
+
+// How many users /referrals shows.
+const topReferrersListed = 20
+
+func (bot *Bot) referralLink(code string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%s", bot.telegram.Self.UserName, referralPrefix, code)
+}
+
+// Remembers who brought the user who started the bot with the payload, if
+// the user is not a member of the group yet.
+func (bot *Bot) trackReferral(u *User, payload string) error {
+	if !strings.HasPrefix(payload, referralPrefix) {
+		return nil
+	}
+	referrer := bot.db.GetUserByReferralCode(strings.TrimPrefix(payload, referralPrefix))
+	if referrer == nil || referrer.ID == u.ID || u.Enlisted {
+		return nil
+	}
+	if err := bot.db.AddReferral(u, referrer); err != nil {
+		return fmt.Errorf("failed to save the referral: %v", err)
+	}
+	log.Printf("%s was referred by %s", u.NameAndTags(), referrer.NameAndTags())
+	return nil
+}
+
+// Counts the referral of the user who has just been enlisted, if the user
+// is eligible.
+func (bot *Bot) recordReferral(u *User) {
+	if !u.Enlisted || u.Banned || (u.Verification != VerificationNone && u.Verification != VerificationPassed) {
+		return
+	}
+	referrerID, err := bot.db.RecordReferral(u)
+	if err != nil {
+		log.Printf("failed to record the referral of %s: %v", u.NameAndTags(), err)
+		return
+	}
+	if referrerID != 0 {
+		log.Printf("recorded the referral of %s by user %d", u.NameAndTags(), referrerID)
+	}
+}
@@ -0,0 +1,88 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// Tells the group that the users can opt in to the event, with a button to
+// join.
+func (bot *Bot) announceRegistration(event *Event) error {
+	tr := bot.groupTr()
+	text := tr.T("registration.open", tr.Coins(event.Coins), event.ScheduledAt.Time.Format(timeFormat))
+	if event.Keyword != "" {
+		text = tr.T("registration.open_keyword", tr.Coins(event.Coins), event.ScheduledAt.Time.Format(timeFormat), event.Keyword)
+	}
+
+	button, err := bot.CallbackButton(tr.T("registration.join"), "join", strconv.Itoa(event.ID))
+	if err != nil {
+		return err
+	}
+	msg := tgbotapi.NewMessage(bot.config.ChatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+	_, err = bot.send(msg.ChatID, PriorityAnnouncement, msg)
+	return err
+}
+
+// Registers the user for the event and tells the outcome.
+func (bot *Bot) optIn(ctx *Context, event *Event) (string, error) {
+	tr := bot.Tr(ctx)
+	u := ctx.User
+	if !event.RegistrationOpen() {
+		return tr.T("registration.closed"), nil
+	}
+	if u.Banned || !u.Enlisted || (bot.eligibility().RequireAddress && u.Address == "") {
+		return tr.T("registration.not_eligible"), nil
+	}
+
+	switch err := bot.db.OptIn(event, u); err {
+	case nil:
+		log.Printf("opted in to event %d: %s", event.ID, u.NameAndTags())
+		return tr.T("registration.joined"), nil
+	case AlreadyOptedIn:
+		return tr.T("registration.already"), nil
+	default:
+		return "", err
+	}
+}
+
+func (bot *Bot) handleCallbackJoin(ctx *Context, payload string) (string, error) {
+	eventID, err := strconv.Atoi(payload)
+	if err != nil {
+		return "", BadCallbackData
+	}
+	event := bot.db.GetCurrentEvent()
+	if event == nil || event.ID != eventID {
+		return "", errors.New(bot.Tr(ctx).T("registration.closed"))
+	}
+	return bot.optIn(ctx, event)
+}
+
+// Registers the users who send the keyword of the event privately or to the
+// group. Returns true if the text was the keyword.
+func (bot *Bot) handleRegistrationKeyword(ctx *Context, text string) (bool, error) {
+	event := bot.db.GetCurrentEvent()
+	if event == nil || event.Keyword == "" || !event.RegistrationOpen() {
+		return false, nil
+	}
+	if !strings.EqualFold(normalizeAnswer(text), event.Keyword) {
+		return false, nil
+	}
+
+	reply, err := bot.optIn(ctx, event)
+	if err != nil {
+		return true, fmt.Errorf("failed to opt in: %v", err)
+	}
+	return true, bot.Reply(ctx, reply)
+}
+
+// Handles the keyword sent privately.
+func (bot *Bot) handleRegistrationMessage(ctx *Context, text string) (bool, error) {
+	registered, err := bot.handleRegistrationKeyword(ctx, text)
+	return !registered, err
+}
@@ -0,0 +1,125 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/telegram-bot-api.v4"
+)
+
+// A point of an event when the participants who have not claimed get
+// reminded: a share of the duration after the start, or a time before the
+// end.
+type reminderPoint struct {
+	percent int
+	before  time.Duration
+}
+
+// Parses the points configured with `claim_reminders`, like "50%" or "10m".
+func parseReminderPoints(texts []string) ([]reminderPoint, error) {
+	var points []reminderPoint
+	for _, text := range texts {
+		if strings.HasSuffix(text, "%") {
+			percent, err := strconv.Atoi(strings.TrimSuffix(text, "%"))
+			if err != nil || percent <= 0 || percent >= 100 {
+				return nil, fmt.Errorf("expected a percent between 0 and 100: %s", text)
+			}
+			points = append(points, reminderPoint{percent: percent})
+			continue
+		}
+
+		before, err := time.ParseDuration(text)
+		if err != nil || before <= 0 {
+			return nil, fmt.Errorf("expected a percent or a positive duration: %s", text)
+		}
+		points = append(points, reminderPoint{before: before})
+	}
+	return points, nil
+}
+
+// Returns when the point comes in the started event.
+func (p reminderPoint) at(event *Event) time.Time {
+	if p.percent > 0 {
+		return event.StartedAt.Time.Add(event.Duration.Duration * time.Duration(p.percent) / 100)
+	}
+	return eventEndsAt(event).Add(-p.before)
+}
+
+// Returns when the participants of the started event are to be reminded
+// next, if at all before the end. The points which passed while the bot was
+// not running are made up for with one reminder.
+func (bot *Bot) nextReminder(event *Event) (time.Time, bool) {
+	var times []time.Time
+	for _, p := range bot.reminders {
+		at := p.at(event)
+		if !at.After(event.StartedAt.Time) || !at.Before(eventEndsAt(event)) {
+			continue
+		}
+		if event.RemindedAt.Valid && !at.After(event.RemindedAt.Time) {
+			continue
+		}
+		times = append(times, at)
+	}
+	if len(times) == 0 {
+		return time.Time{}, false
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times[0], true
+}
+
+// Privately reminds the participants of the event who have not claimed yet.
+// The users who cannot be messaged are not tried again until they write to
+// the bot.
+func (bot *Bot) remindClaimers(event *Event) {
+	users, err := bot.db.GetClaimersToRemind(event)
+	if err != nil {
+		log.Printf("failed to get the participants to remind: %v", err)
+		return
+	}
+
+	var reminded, unreachable int
+	for i := range users {
+		u := &users[i]
+		err := bot.remindClaimer(event, u)
+		switch {
+		case err == nil:
+			reminded++
+		case isForbidden(err):
+			unreachable++
+			if err := bot.db.SetUnreachable(u, true); err != nil {
+				log.Printf("failed to mark %s unreachable: %v", u.NameAndTags(), err)
+			}
+		default:
+			log.Printf("failed to remind %s to claim: %v", u.NameAndTags(), err)
+		}
+	}
+	log.Printf("reminded %d participants of event %d to claim, %d unreachable", reminded, event.ID, unreachable)
+}
+
+func (bot *Bot) remindClaimer(event *Event, u *User) error {
+	coins, err := bot.db.GetCoinsToClaim(u, event)
+	if err != nil {
+		// claimed or left meanwhile
+		return nil
+	}
+
+	tr := bot.userTr(u)
+	msg := tgbotapi.NewMessage(int64(u.ID), tr.T(
+		"reminder.claim",
+		tr.Coins(coins),
+		tr.Duration(time.Until(eventEndsAt(event)).Truncate(time.Minute)),
+	))
+	if u.Address != "" {
+		button, err := bot.CallbackButton(tr.T("claim.use_saved"), "claim", strconv.Itoa(event.ID))
+		if err != nil {
+			return err
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button))
+	}
+	_, err = bot.send(msg.ChatID, PriorityChatter, msg)
+	return err
+}
@@ -0,0 +1,78 @@
+package skyaway
+
+import (
+	"fmt"
+	"log"
+)
+
+// What happens to the coins nobody has claimed by the end of an event.
+const (
+	RolloverWallet   = "wallet"   // they stay in the wallet
+	RolloverNext     = "next"     // they are added to the next event to start
+	RolloverClaimers = "claimers" // a second round starts among those who claimed
+)
+
+func isRollover(policy string) bool {
+	switch policy {
+	case RolloverWallet, RolloverNext, RolloverClaimers:
+		return true
+	}
+	return false
+}
+
+// Records the coins left unclaimed in the ended event and starts the second
+// round if the event asks for one. Returns the second round, or nil.
+func (bot *Bot) rollOver(event *Event) (*Event, error) {
+	coins, err := bot.db.CoinsUnclaimed(event)
+	if err != nil {
+		return nil, err
+	}
+	if coins < 0 {
+		coins = 0
+	}
+	if err := bot.db.SetLeftover(event, coins); err != nil {
+		return nil, fmt.Errorf("failed to record the leftover: %v", err)
+	}
+	if coins == 0 || event.Rollover != RolloverClaimers {
+		return nil, nil
+	}
+
+	round, err := bot.db.StartSecondRound(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the second round: %v", err)
+	}
+	if round != nil {
+		log.Printf("second round %d of event %d started with %d coins", round.ID, event.ID, round.Coins)
+	}
+	return round, nil
+}
+
+// Describes what happened to the coins left unclaimed in the ended event.
+// Returns an empty string if nothing was left.
+func describeLeftover(tr Translator, event *Event) string {
+	if !event.Leftover.Valid || event.Leftover.Int64 <= 0 {
+		return ""
+	}
+	coins := tr.Coins(int(event.Leftover.Int64))
+	switch {
+	case event.Rollover == RolloverNext:
+		return tr.T("rollover.next", coins)
+	case event.Rollover == RolloverClaimers && event.RolledInto.Valid:
+		return tr.T("rollover.claimers", coins)
+	default:
+		return tr.T("rollover.wallet", coins)
+	}
+}
+
+// Announces the end of the event along with what happened to the leftover,
+// and the second round if one has started.
+func (bot *Bot) announceEnded(event *Event) {
+	round, err := bot.rollOver(event)
+	if err != nil {
+		log.Printf("failed to roll over the leftover of event %d: %v", event.ID, err)
+	}
+	bot.AnnounceEvent(event, StageEnded)
+	if round != nil {
+		bot.AnnounceEvent(round, StageStarted)
+	}
+}
@@ -1,7 +1,6 @@
 package skyaway
 
 import (
-	"fmt"
 	"log"
 	"time"
 )
@@ -11,8 +10,10 @@ type task int
 const (
 	nothing task = iota
 	announceEventStart
+	openRegistration
 	startEvent
 	announceEventEnd
+	remindClaimers
 	endEvent
 )
 
@@ -25,6 +26,8 @@ func (bot *Bot) schedule() (task, time.Time) {
 
 	if event.StartedAt.Valid {
 		return endEvent, event.StartedAt.Time.Add(event.Duration.Duration)
+	} else if event.Registration.Valid && time.Now().Before(event.RegistrationOpensAt()) {
+		return openRegistration, event.RegistrationOpensAt()
 	} else if event.ScheduledAt.Valid {
 		return startEvent, event.ScheduledAt.Time
 	}
@@ -35,7 +38,7 @@ func (bot *Bot) schedule() (task, time.Time) {
 }
 
 // Returns a more detailed version than `schedule()`
-// of what to do next (including announcements).
+// of what to do next (including announcements and claim reminders).
 func (bot *Bot) subSchedule() (task, time.Time) {
 	tsk, future := bot.schedule()
 	if tsk == nothing {
@@ -44,21 +47,28 @@ func (bot *Bot) subSchedule() (task, time.Time) {
 
 	every := bot.config.AnnounceEvery.Duration
 
-	announcements := time.Until(future) / every
-	if announcements <= 0 {
-		return tsk, future
+	next, nearFuture := tsk, future
+	if announcements := time.Until(future) / every; announcements > 0 {
+		nearFuture = future.Add(-announcements * every)
+		switch tsk {
+		case openRegistration, startEvent:
+			next = announceEventStart
+		case endEvent:
+			next = announceEventEnd
+		default:
+			log.Print("unsupported task to subSchedule")
+			return nothing, time.Time{}
+		}
 	}
 
-	nearFuture := future.Add(-announcements * every)
-	switch tsk {
-	case startEvent:
-		return announceEventStart, nearFuture
-	case endEvent:
-		return announceEventEnd, nearFuture
-	default:
-		log.Print("unsupported task to subSchedule")
-		return nothing, time.Time{}
+	if tsk == endEvent {
+		if event := bot.db.GetCurrentEvent(); event != nil {
+			if at, ok := bot.nextReminder(event); ok && at.Before(nearFuture) {
+				return remindClaimers, at
+			}
+		}
 	}
+	return next, nearFuture
 }
 
 func (bot *Bot) perform(tsk task) {
@@ -68,7 +78,6 @@ func (bot *Bot) perform(tsk task) {
 		return
 	}
 
-	noctx := &Context{}
 	switch tsk {
 	case announceEventStart:
 		if event.Surprise {
@@ -77,41 +86,41 @@ func (bot *Bot) perform(tsk task) {
 		}
 
 		log.Print("announcing the event future start")
-		if err := bot.AnnounceEventWithTitle(event, "Event is scheduled"); err != nil {
+		if err := bot.AnnounceEvent(event, StageReminder); err != nil {
 			log.Printf("failed to announce event future start: %v", err)
 		}
 	case announceEventEnd:
 		log.Print("announcing the event future end")
-		if err := bot.AnnounceEventWithTitle(event, "Event is ongoing"); err != nil {
+		if err := bot.AnnounceEvent(event, StageOngoing); err != nil {
 			log.Printf("failed to announce event future end: %v", err)
 		}
-	case startEvent:
-		log.Print("starting the event")
+	case remindClaimers:
+		log.Print("reminding the participants to claim")
 
-		startedEvent, err := bot.StartCurrentEvent()
-		if err != nil {
-			log.Printf("failed to start event: %v", err)
+		// the next reminder gets scheduled after this one
+		if err := bot.db.SetReminded(event, time.Now()); err != nil {
+			log.Printf("failed to save the reminder time: %v", err)
 			break
 		}
+		go bot.remindClaimers(event)
+	case openRegistration:
+		log.Print("opening the registration")
+		if err := bot.announceRegistration(event); err != nil {
+			log.Printf("failed to announce the registration: %v", err)
+		}
+	case startEvent:
+		log.Print("starting the event")
 
-		md := formatEventAsMarkdown(startedEvent, true)
-		md = fmt.Sprintf("*%s*\n%s", "Event has started!", md)
-		if err := bot.Send(noctx, "yell", "markdown", md); err != nil {
-			log.Printf("failed to announce event started: %v", err)
+		// the start gets announced by StartCurrentEvent
+		if _, err := bot.StartCurrentEvent(); err != nil {
+			log.Printf("failed to start event: %v", err)
 		}
 	case endEvent:
 		log.Print("ending the event")
 
-		endedEvent, err := bot.EndCurrentEvent()
-		if err != nil {
+		// the end gets announced by EndCurrentEvent
+		if _, err := bot.EndCurrentEvent(); err != nil {
 			log.Printf("failed to end event: %v", err)
-			break
-		}
-
-		md := formatEventAsMarkdown(endedEvent, true)
-		md = fmt.Sprintf("*%s*\n%s", "Event has ended!", md)
-		if err := bot.Send(noctx, "yell", "markdown", md); err != nil {
-			log.Printf("failed to announce event ended: %v", err)
 		}
 	default:
 		log.Printf("unsupported task to perform: %v", tsk)
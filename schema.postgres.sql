@@ -7,7 +7,60 @@ CREATE TABLE botuser (
   last_name  TEXT,
   enlisted   BOOL            NOT NULL DEFAULT TRUE, -- is in the group
   banned     BOOL            NOT NULL DEFAULT FALSE, -- is disabled even if in the group
-  admin      BOOL            NOT NULL DEFAULT FALSE  -- can issue commands
+  admin      BOOL            NOT NULL DEFAULT FALSE, -- can issue commands
+  language   TEXT            NOT NULL DEFAULT '', -- chosen with /language, empty for the client language
+  address    TEXT            NOT NULL DEFAULT '', -- default payout address saved with /setaddress
+  verification TEXT          NOT NULL DEFAULT '', -- '', pending, passed, failed or timeout
+  ban_reason   TEXT          NOT NULL DEFAULT '',
+  banned_by    INT, -- the admin who banned the user
+  banned_until TIMESTAMP WITH TIME zone, -- null if banned permanently
+  referral_code TEXT UNIQUE, -- for the personal /start link, generated on request
+  reminders    BOOL          NOT NULL DEFAULT TRUE, -- gets private reminders to claim, turned off with /reminders
+  unreachable  BOOL          NOT NULL DEFAULT FALSE -- cannot be messaged privately until writes to the bot
+);
+
+-- Verification challenges sent to new members, until answered or expired.
+CREATE TABLE challenge (
+  user_id    INT    PRIMARY KEY NOT NULL REFERENCES botuser (id),
+  answer     TEXT   NOT NULL,
+  chat_id    BIGINT NOT NULL, -- where the challenge has been sent
+  message_id INT    NOT NULL,
+  expires_at TIMESTAMP WITH TIME zone NOT NULL
+);
+
+-- Who brought whom with the /start link. The referral counts once the user
+-- joins the group and becomes eligible.
+CREATE TABLE referral (
+  user_id     INT PRIMARY KEY NOT NULL REFERENCES botuser (id),
+  referrer_id INT NOT NULL REFERENCES botuser (id),
+  started_at  TIMESTAMP WITH TIME zone NOT NULL DEFAULT now(),
+  recorded_at TIMESTAMP WITH TIME zone -- null until the user is enlisted and verified
+);
+
+-- The former names of the users, kept when telegram reports new ones.
+CREATE TABLE user_alias (
+  user_id    INT  NOT NULL REFERENCES botuser (id),
+  username   TEXT,
+  first_name TEXT,
+  last_name  TEXT,
+  changed_at TIMESTAMP WITH TIME zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_alias_username ON user_alias (username);
+
+-- The number of messages each user posted to the group by day.
+CREATE TABLE activity (
+  user_id  INT  NOT NULL REFERENCES botuser (id),
+  day      DATE NOT NULL DEFAULT current_date,
+  messages INT  NOT NULL DEFAULT 0,
+  PRIMARY KEY (user_id, day)
+);
+
+-- The history of the saved payout addresses.
+CREATE TABLE address_change (
+  user_id    INT  NOT NULL REFERENCES botuser (id),
+  address    TEXT NOT NULL,
+  changed_at TIMESTAMP WITH TIME zone NOT NULL DEFAULT now()
 );
 
 -- Only one event with null `ended_at` should exist, it is considered the
@@ -20,7 +73,14 @@ CREATE TABLE event (
   started_at     TIMESTAMP WITH TIME zone, -- null if not started yet or canceled
   ended_at       TIMESTAMP WITH TIME zone, -- null if current event
   coins          INT     NOT NULL,
-  surprise       BOOLEAN NOT NULL -- no automatic announcements
+  surprise       BOOLEAN NOT NULL, -- no automatic announcements
+  message_id     INT, -- the pinned announcement, if `pin_events` is on
+  registration   BIGINT, -- nanoseconds before the start when users can opt in, null if everyone participates
+  keyword        TEXT    NOT NULL DEFAULT '', -- to opt in by sending it, empty for the button only
+  rollover       TEXT    NOT NULL DEFAULT 'wallet', -- what happens to the unclaimed coins: wallet, next or claimers
+  leftover       INT, -- coins unclaimed at the end, null until ended
+  rolled_into    INT REFERENCES event (id), -- the event which got the leftover, null if none
+  reminded_at    TIMESTAMP WITH TIME zone -- when the participants were last reminded to claim
 );
 
 -- This table keeps track of user claims in events. The current list of users
@@ -33,5 +93,58 @@ CREATE TABLE participant (
   username   TEXT,
   coins      INT NOT NULL, -- precalculated number of coins for the user
   claimed_at TIMESTAMP WITH TIME zone, -- null if not claimed yet
+  address    TEXT NOT NULL DEFAULT '', -- where the coins are sent, empty if not claimed yet
+  txid       TEXT NOT NULL DEFAULT '', -- of the transaction, empty if not sent yet
+  held       BOOL NOT NULL DEFAULT FALSE, -- the payout waits for an admin review
+  opted_in_at TIMESTAMP WITH TIME zone, -- when registered for an opt-in event, null otherwise
   PRIMARY KEY (event_id, user_id)
 );
+
+-- The question of a quiz event. Nobody participates in a quiz event until
+-- answering correctly.
+CREATE TABLE quiz (
+  event_id   INT     PRIMARY KEY NOT NULL REFERENCES event (id),
+  question   TEXT    NOT NULL,
+  answers    TEXT    NOT NULL, -- the correct answers, one per line
+  options    TEXT    NOT NULL DEFAULT '', -- the choices offered, one per line, for the choice quizzes
+  match      TEXT    NOT NULL, -- how the answers are checked: exact, regex or choice
+  winners    INT     NOT NULL, -- the first correct answerers who get coins, 0 to split among all of them
+  answer_for BIGINT  NOT NULL, -- nanoseconds since the start to accept answers
+  message_id INT, -- the question posted to the group
+  closed     BOOLEAN NOT NULL DEFAULT FALSE -- no more answers, the coins are allocated
+);
+
+CREATE TABLE quiz_answer (
+  event_id    INT     NOT NULL REFERENCES quiz (event_id),
+  user_id     INT     NOT NULL REFERENCES botuser (id),
+  answer      TEXT    NOT NULL,
+  correct     BOOLEAN NOT NULL,
+  answered_at TIMESTAMP WITH TIME zone NOT NULL DEFAULT now(),
+  PRIMARY KEY (event_id, user_id) -- only the first answer counts
+);
+
+-- Pairs of claim addresses which the node reports as spent together, so
+-- they probably belong to the same person. `a` < `b`.
+CREATE TABLE address_link (
+  a TEXT NOT NULL,
+  b TEXT NOT NULL,
+  PRIMARY KEY (a, b)
+);
+
+-- Settings of the chats the bot talks in.
+CREATE TABLE chat (
+  id              BIGINT  PRIMARY KEY NOT NULL, -- telegram chat id
+  language        TEXT    NOT NULL DEFAULT '', -- chosen with /chatlanguage
+  require_address BOOLEAN NOT NULL DEFAULT FALSE, -- only users with a saved address participate
+  captcha         TEXT    NOT NULL DEFAULT '', -- space separated challenge kinds for new members, empty if off
+  captcha_dm      BOOLEAN NOT NULL DEFAULT FALSE, -- send challenges privately when possible
+  distribution    TEXT    NOT NULL DEFAULT 'equal', -- how the coins are split: equal, activity or referrals
+  max_share       INT     NOT NULL DEFAULT 0 -- percent of the coins one participant may get, 0 for no limit
+);
+
+-- Announcement templates edited with /settemplate. They override the ones
+-- from the config.
+CREATE TABLE template (
+  name TEXT PRIMARY KEY NOT NULL, -- event stage: scheduled, reminder, started, ongoing, ended, cancelled
+  text TEXT NOT NULL -- text/template producing markdown
+);
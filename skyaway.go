@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"text/template"
 
 	"gopkg.in/telegram-bot-api.v4"
 )
@@ -13,16 +15,29 @@ type Bot struct {
 	config                 *Config
 	db                     *DB
 	telegram               *tgbotapi.BotAPI
+	commands               Commands
 	commandHandlers        map[string]CommandHandler
 	adminCommandHandlers   map[string]CommandHandler
 	privateMessageHandlers []MessageHandler
 	groupMessageHandlers   []MessageHandler
+	callbackHandlers       map[string]CallbackHandler
+	confirmations          map[string]*confirmation
+	pagers                 map[string]*pager
+	leaderboards           *leaderboardCache
+	adminChecks            *adminChecks
+	activity               *activityCounts
+	reminders              []reminderPoint
+	templates              map[string]*template.Template
+	templatesLock          sync.RWMutex // the templates get reloaded while announcements are made
+	outbox                 *outbox
+	syncing                int32 // set while a membership sync runs, accessed atomically
 	rescheduleChan         chan int
 }
 
 type Context struct {
-	message *tgbotapi.Message
-	User    *User
+	message  *tgbotapi.Message
+	callback *tgbotapi.CallbackQuery
+	User     *User
 }
 
 type CommandHandler func(*Bot, *Context, string, string) error
@@ -39,13 +54,18 @@ func (bot *Bot) StartCurrentEvent() (*Event, error) {
 		return nil, EventDoesNotExist
 	}
 
-	err := bot.db.StartEvent(event)
+	err := bot.db.StartEvent(event, bot.eligibility())
 	if err != nil {
 		return nil, fmt.Errorf("failed to start current event: %v", err)
 	}
 	defer bot.Reschedule()
 
-	bot.AnnounceEventWithTitle(event, "Event has started!")
+	bot.AnnounceEvent(event, StageStarted)
+	if quiz := bot.db.GetQuiz(event.ID); quiz != nil {
+		if err := bot.postQuiz(event, quiz); err != nil {
+			log.Printf("failed to post the quiz: %v", err)
+		}
+	}
 
 	return event, nil
 }
@@ -63,14 +83,15 @@ func (bot *Bot) EndCurrentEvent() (*Event, error) {
 		return nil, fmt.Errorf("failed to end current event: %v", err)
 	}
 	defer bot.Reschedule()
+	bot.leaderboards.clear()
 
 	switch {
 	case event.StartedAt.Valid:
-		bot.AnnounceEventWithTitle(event, "Event has ended!")
+		bot.announceEnded(event)
 	case event.ScheduledAt.Valid:
 		// Make a cancel announcement only if it is a public event
 		if !event.Surprise {
-			bot.AnnounceEventWithTitle(event, "The scheduled event has been cancelled")
+			bot.AnnounceEvent(event, StageCancelled)
 		}
 	default:
 		log.Printf("the ended event was neither started, nor scheduled")
@@ -108,7 +129,7 @@ func (bot *Bot) EndCurrentEventIfNeeded() (event *Event, ended bool, err error)
 		err = fmt.Errorf("failed to end current event: %v", err)
 		return
 	}
-	bot.AnnounceEventWithTitle(event, "Event has ended!")
+	bot.announceEnded(event)
 	defer bot.Reschedule()
 	ended = true
 	return
@@ -124,7 +145,7 @@ func (bot *Bot) StartNewEvent(coins int, duration Duration) (*Event, error) {
 		return event, EventExists
 	}
 
-	err := bot.db.StartNewEvent(coins, duration)
+	err := bot.db.StartNewEvent(coins, duration, bot.eligibility())
 	if err != nil {
 		return nil, fmt.Errorf("failed to start event: %v", err)
 	}
@@ -135,10 +156,21 @@ func (bot *Bot) StartNewEvent(coins int, duration Duration) (*Event, error) {
 		return nil, fmt.Errorf("event did not start due to reasons unknown")
 	}
 
-	bot.AnnounceEventWithTitle(event, "Event has started!")
+	bot.AnnounceEvent(event, StageStarted)
 	return event, nil
 }
 
+// Returns the conditions for the users to participate in the events of the
+// group.
+func (bot *Bot) eligibility() Eligibility {
+	distribution, maxShare := bot.db.GetDistribution(bot.config.ChatID)
+	return Eligibility{
+		RequireAddress: bot.db.GetRequireAddress(bot.config.ChatID),
+		Distribution:   distribution,
+		MaxShare:       maxShare,
+	}
+}
+
 func (bot *Bot) enableUser(u *User) ([]string, error) {
 	var actions []string
 	if !u.Exists() {
@@ -152,23 +184,35 @@ func (bot *Bot) enableUser(u *User) ([]string, error) {
 		u.Enlisted = true
 		actions = append(actions, "enlisted")
 	}
+	if u.Verification != VerificationNone && u.Verification != VerificationPassed {
+		if err := bot.db.DeleteChallenge(u.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete the challenge: %v", err)
+		}
+		u.Verification = VerificationPassed
+		actions = append(actions, "verified")
+	}
 	if len(actions) > 0 {
 		if err := bot.db.PutUser(u); err != nil {
 			return nil, fmt.Errorf("failed to change user status: %v", err)
 		}
+		bot.recordReferral(u)
 	}
 	return actions, nil
 }
 
 func (bot *Bot) handleForwardedMessageFrom(ctx *Context, id int) error {
-	args := tgbotapi.ChatConfigWithUser{bot.config.ChatID, "", id}
-	member, err := bot.telegram.GetChatMember(args)
+	args := tgbotapi.ChatConfigWithUser{ChatID: bot.config.ChatID, UserID: id}
+	var member tgbotapi.ChatMember
+	err := bot.call(0, PriorityChatter, func() (err error) {
+		member, err = bot.telegram.GetChatMember(args)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to get chat member from telegram: %v", err)
 	}
 
 	if !member.IsMember() && !member.IsCreator() && !member.IsAdministrator() {
-		return bot.Reply(ctx, "that user is not a member of the chat")
+		return bot.Reply(ctx, bot.Tr(ctx).T("adduser.not_member"))
 	}
 
 	user := member.User
@@ -187,6 +231,12 @@ func (bot *Bot) handleForwardedMessageFrom(ctx *Context, id int) error {
 }
 
 func (bot *Bot) handleCommand(ctx *Context, command, args string) error {
+	if _, found := bot.adminCommandHandlers[command]; found {
+		if err := bot.refreshAdmin(ctx.User); err != nil {
+			log.Printf("failed to refresh the admin status of %s: %v", ctx.User.NameAndTags(), err)
+		}
+	}
+
 	if !ctx.User.Banned {
 		handler, found := bot.commandHandlers[command]
 		if found {
@@ -205,6 +255,13 @@ func (bot *Bot) handleCommand(ctx *Context, command, args string) error {
 }
 
 func (bot *Bot) handlePrivateMessage(ctx *Context) error {
+	// the user can be reminded privately again
+	if ctx.User.Unreachable {
+		if err := bot.db.SetUnreachable(ctx.User, false); err != nil {
+			log.Printf("failed to mark %s reachable: %v", ctx.User.NameAndTags(), err)
+		}
+	}
+
 	if ctx.User.Admin {
 		// let admin force add users by forwarding their messages
 		if u := ctx.message.ForwardFrom; u != nil {
@@ -220,7 +277,7 @@ func (bot *Bot) handlePrivateMessage(ctx *Context) error {
 		err := bot.handleCommand(ctx, cmd, args)
 		if err != nil {
 			log.Printf("command '/%s %s' failed: %v", cmd, args, err)
-			return bot.Reply(ctx, fmt.Sprintf("command failed: %v", err))
+			return bot.Reply(ctx, bot.Tr(ctx).T("command.failed", err))
 		}
 		return nil
 	}
@@ -253,9 +310,8 @@ func (bot *Bot) handleUserJoin(ctx *Context, user *tgbotapi.User) error {
 			LastName:  user.LastName,
 		}
 	}
-	dbuser.Enlisted = true
-	if err := bot.db.PutUser(dbuser); err != nil {
-		log.Printf("failed to save the user")
+	if err := bot.admitUser(dbuser); err != nil {
+		log.Printf("failed to admit the user")
 		return err
 	}
 
@@ -320,6 +376,27 @@ func (bot *Bot) handleGroupMessage(ctx *Context) error {
 		}
 	}
 
+	if u := ctx.User; u != nil && !u.Enlisted && !u.Banned && ctx.message.NewChatMembers == nil && ctx.message.LeftChatMember == nil {
+		// whoever writes to the group is a member, even if the bot has
+		// missed the join
+		if u.Verification == VerificationNone || u.Verification == VerificationPassed {
+			if err := bot.admitUser(u); err != nil {
+				gerr = err
+			}
+		}
+	}
+
+	if u := ctx.User; u != nil && !ctx.message.From.IsBot && ctx.message.NewChatMembers == nil && ctx.message.LeftChatMember == nil {
+		bot.activity.count(u.ID)
+	}
+
+	if ctx.User != nil && ctx.message.Text != "" {
+		// the keyword counts without mentioning the bot
+		if registered, err := bot.handleRegistrationKeyword(ctx, ctx.message.Text); registered {
+			return err
+		}
+	}
+
 	if ctx.User != nil {
 		msgWithoutName, mentioned := bot.removeMyName(ctx.message.Text)
 
@@ -341,14 +418,16 @@ func (bot *Bot) handleGroupMessage(ctx *Context) error {
 
 func (bot *Bot) Send(ctx *Context, mode, format, text string) error {
 	var msg tgbotapi.MessageConfig
+	priority := PriorityChatter
 	switch mode {
 	case "whisper":
-		msg = tgbotapi.NewMessage(int64(ctx.message.From.ID), text)
+		msg = tgbotapi.NewMessage(int64(ctx.User.ID), text)
 	case "reply":
 		msg = tgbotapi.NewMessage(ctx.message.Chat.ID, text)
 		msg.ReplyToMessageID = ctx.message.MessageID
 	case "yell":
 		msg = tgbotapi.NewMessage(bot.config.ChatID, text)
+		priority = PriorityAnnouncement
 	default:
 		return fmt.Errorf("unsupported message mode: %s", mode)
 	}
@@ -362,14 +441,47 @@ func (bot *Bot) Send(ctx *Context, mode, format, text string) error {
 	default:
 		return fmt.Errorf("unsupported message format: %s", format)
 	}
-	_, err := bot.telegram.Send(msg)
+	if mode == "reply" {
+		// there is nobody else to tell about a failed reply, so it is not
+		// waited for, the outbox logs the failure
+		bot.post(msg.ChatID, priority, msg)
+		return nil
+	}
+	_, err := bot.send(msg.ChatID, priority, msg)
 	return err
+}
+
+// Sends the message through the outbox, waiting for it to be sent.
+func (bot *Bot) send(chatID int64, priority int, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return bot.outbox.send(chatID, priority, func() (tgbotapi.Message, error) {
+		return bot.telegram.Send(c)
+	})
+}
+
+// Sends the message through the outbox without waiting for it to be sent.
+func (bot *Bot) post(chatID int64, priority int, c tgbotapi.Chattable) {
+	bot.postThen(chatID, priority, c, nil)
+}
+
+// Sends the message through the outbox without waiting for it to be sent,
+// and passes the result to `done`.
+func (bot *Bot) postThen(chatID int64, priority int, c tgbotapi.Chattable, done func(tgbotapi.Message, error)) {
+	bot.outbox.post(chatID, priority, func() (tgbotapi.Message, error) {
+		return bot.telegram.Send(c)
+	}, done)
+}
 
+// Makes a request which does not return a message through the outbox.
+func (bot *Bot) call(chatID int64, priority int, do func() error) error {
+	_, err := bot.outbox.send(chatID, priority, func() (tgbotapi.Message, error) {
+		return tgbotapi.Message{}, do()
+	})
+	return err
 }
 
 func (bot *Bot) ReplyAboutEvent(ctx *Context, text string, event *Event) error {
 	return bot.Send(ctx, "reply", "markdown", fmt.Sprintf(
-		"%s\n%s", text, formatEventAsMarkdown(event, false),
+		"%s\n%s", text, formatEventAsMarkdown(bot.Tr(ctx), event, false),
 	))
 }
 
@@ -380,7 +492,7 @@ func (bot *Bot) Ask(ctx *Context, text string) error {
 		Selective:  true,
 	}
 	msg.ReplyToMessageID = ctx.message.MessageID
-	_, err := bot.telegram.Send(msg)
+	_, err := bot.send(msg.ChatID, PriorityChatter, msg)
 	return err
 }
 
@@ -404,6 +516,13 @@ func NewBot(config Config) (*Bot, error) {
 		config:               &config,
 		commandHandlers:      make(map[string]CommandHandler),
 		adminCommandHandlers: make(map[string]CommandHandler),
+		callbackHandlers:     make(map[string]CallbackHandler),
+		confirmations:        make(map[string]*confirmation),
+		pagers:               make(map[string]*pager),
+		leaderboards:         newLeaderboardCache(),
+		adminChecks:          newAdminChecks(),
+		activity:             newActivityCounts(),
+		outbox:               newOutbox(),
 	}
 	var err error
 
@@ -411,13 +530,21 @@ func NewBot(config Config) (*Bot, error) {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
 
+	if err = bot.loadTemplates(); err != nil {
+		return nil, fmt.Errorf("failed to load templates: %v", err)
+	}
+
+	if bot.reminders, err = parseReminderPoints(config.ClaimReminders); err != nil {
+		return nil, fmt.Errorf("bad claim reminders: %v", err)
+	}
+
 	if bot.telegram, err = tgbotapi.NewBotAPI(config.Token); err != nil {
 		return nil, fmt.Errorf("failed to initialize telegram api: %v", err)
 	}
 
 	bot.telegram.Debug = config.Debug
 
-	chat, err := bot.telegram.GetChat(tgbotapi.ChatConfig{config.ChatID, ""})
+	chat, err := bot.telegram.GetChat(tgbotapi.ChatConfig{ChatID: config.ChatID})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chat info from telegram: %v", err)
 	}
@@ -428,12 +555,51 @@ func NewBot(config Config) (*Bot, error) {
 	log.Printf("user: %d %s", bot.telegram.Self.ID, bot.telegram.Self.UserName)
 	log.Printf("chat: %s %d %s", chat.Type, chat.ID, chat.Title)
 
+	go bot.outbox.run()
+
 	bot.setCommandHandlers()
+	if err := bot.publishCommands(); err != nil {
+		log.Printf("failed to publish commands: %v", err)
+	}
 
 	return &bot, nil
 }
 
+// Returns the user from the database, adding the user if not tracked yet.
+func (bot *Bot) trackUser(u *tgbotapi.User) (*User, error) {
+	dbuser := bot.db.GetUser(u.ID)
+	if dbuser == nil {
+		log.Printf("message from untracked user: %s, adding to db", u.String())
+
+		dbuser = &User{
+			ID:        u.ID,
+			UserName:  u.UserName,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+		}
+		if err := bot.db.PutUser(dbuser); err != nil {
+			return nil, fmt.Errorf("failed to save the user: %v", err)
+		}
+	} else if dbuser.UserName != u.UserName || dbuser.FirstName != u.FirstName || dbuser.LastName != u.LastName {
+		log.Printf("user renamed: %s to %s", dbuser.NameAndTags(), u.String())
+		if err := bot.db.RenameUser(dbuser, u.UserName, u.FirstName, u.LastName); err != nil {
+			return nil, err
+		}
+	}
+	return dbuser, nil
+}
+
 func (bot *Bot) handleUpdate(update *tgbotapi.Update) error {
+	if q := update.CallbackQuery; q != nil {
+		ctx := Context{message: q.Message, callback: q}
+		dbuser, err := bot.trackUser(q.From)
+		if err != nil {
+			return err
+		}
+		ctx.User = dbuser
+		return bot.handleCallbackQuery(&ctx)
+	}
+
 	if update.Message == nil {
 		return nil
 	}
@@ -441,19 +607,9 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) error {
 	ctx := Context{message: update.Message}
 
 	if u := ctx.message.From; u != nil {
-		dbuser := bot.db.GetUser(u.ID)
-		if dbuser == nil {
-			log.Printf("message from untracked user: %s, adding to db", u.String())
-
-			dbuser = &User{
-				ID:        u.ID,
-				UserName:  u.UserName,
-				FirstName: u.FirstName,
-				LastName:  u.LastName,
-			}
-			if err := bot.db.PutUser(dbuser); err != nil {
-				return fmt.Errorf("failed to save the user: %v", err)
-			}
+		dbuser, err := bot.trackUser(u)
+		if err != nil {
+			return err
 		}
 		ctx.User = dbuser
 	}
@@ -461,9 +617,16 @@ func (bot *Bot) handleUpdate(update *tgbotapi.Update) error {
 	return bot.handleMessage(&ctx)
 }
 
-func (bot *Bot) AnnounceEventWithTitle(event *Event, title string) error {
-	md := formatEventAsMarkdown(event, true)
-	md = fmt.Sprintf("*%s*\n%s", title, md)
+// Announces the event at the lifecycle stage in the group.
+func (bot *Bot) AnnounceEvent(event *Event, stage string) error {
+	if bot.config.PinEvents {
+		return bot.UpdatePinnedEvent(event, stage)
+	}
+
+	md, err := bot.renderAnnouncement(event, stage, false)
+	if err != nil {
+		return err
+	}
 	return bot.Send(&Context{}, "yell", "markdown", md)
 }
 
@@ -477,6 +640,11 @@ func (bot *Bot) Start() error {
 	}
 
 	go bot.maintain()
+	go bot.expireChallenges()
+	go bot.liftBans()
+	go bot.syncMembership()
+	go bot.closeQuizzes()
+	go bot.flushActivity()
 
 	for update := range updates {
 		if err := bot.handleUpdate(&update); err != nil {
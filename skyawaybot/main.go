@@ -46,11 +46,62 @@ func Goodbye(bot *skyaway.Bot, ctx *skyaway.Context, command, args string) error
 	return nil
 }
 
+// Writes a document to stdout:
+//
+//	skyawaybot export winners <event|last|current> [csv|json]
+//	skyawaybot export users [csv|json]
+func export(config skyaway.Config, args []string) error {
+	usage := fmt.Errorf("usage: %s export winners <event|last|current> [csv|json] | users [csv|json]", os.Args[0])
+	if len(args) == 0 {
+		return usage
+	}
+
+	db, err := skyaway.NewDB(&config.Database)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	format := skyaway.ExportCSV
+	switch args[0] {
+	case "winners":
+		if len(args) < 2 || len(args) > 3 {
+			return usage
+		}
+		if len(args) == 3 {
+			format = args[2]
+		}
+		event, err := db.FindEvent(args[1])
+		if err != nil {
+			return err
+		}
+		return db.ExportWinners(os.Stdout, event, format)
+	case "users":
+		if len(args) > 2 {
+			return usage
+		}
+		if len(args) == 2 {
+			format = args[1]
+		}
+		return db.ExportUsers(os.Stdout, format)
+	default:
+		return usage
+	}
+}
+
 func main() {
 	var config skyaway.Config
 	if err := loadJsonFromFile("config.json", &config); err != nil {
 		panic(err)
 	}
+
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := export(config, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	bot, err := skyaway.NewBot(config)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,112 @@
+package skyaway
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// The reason recorded for the users banned from /suspicious.
+const suspiciousBanReason = "claims shared with other accounts"
+
+// Looks for other users who claimed to the same address, and holds the
+// payouts of all of them in the event if there are any. Returns true if the
+// claim has been held. The addresses linked with it are looked up in the
+// background, since asking the node may take a while.
+func (bot *Bot) checkClaim(user *User, event *Event, address string) (bool, error) {
+	go bot.checkLinkedAddresses(user, event, address)
+
+	suspects, err := bot.db.GetAddressClaimers(address, user)
+	if err != nil {
+		return false, err
+	}
+	if len(suspects) == 0 {
+		return false, nil
+	}
+
+	log.Printf("holding the claim of %s to %s, shared with users %v", user.NameAndTags(), address, suspects)
+	if err := bot.db.HoldClaims(event, append(suspects, user.ID)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Looks for other users who claimed to the addresses linked with the address
+// by the node, and holds the payouts of all of them in the event if there
+// are any.
+func (bot *Bot) checkLinkedAddresses(user *User, event *Event, address string) {
+	linked, err := bot.linkedAddresses(address)
+	if err != nil {
+		// the node being down should not prevent claims
+		log.Printf("failed to get addresses linked to %s: %v", address, err)
+		return
+	}
+
+	var suspects []int
+	for _, other := range linked {
+		claimers, err := bot.db.GetAddressClaimers(other, user)
+		if err != nil {
+			log.Printf("failed to check the claim of %s: %v", user.NameAndTags(), err)
+			return
+		}
+		if len(claimers) == 0 {
+			continue
+		}
+		if err := bot.db.AddAddressLink(address, other); err != nil {
+			log.Printf("failed to save the address link: %v", err)
+			return
+		}
+		suspects = append(suspects, claimers...)
+	}
+	if len(suspects) == 0 {
+		return
+	}
+
+	log.Printf("holding the claim of %s to %s, linked with users %v", user.NameAndTags(), address, suspects)
+	if err := bot.db.HoldClaims(event, append(suspects, user.ID)); err != nil {
+		log.Printf("failed to hold the claims: %v", err)
+	}
+}
+
+// Returns the user the admin pressed the button about.
+func (bot *Bot) callbackUser(ctx *Context, payload string) (*User, error) {
+	if !ctx.User.Admin {
+		return nil, errors.New(bot.Tr(ctx).T("callback.not_allowed"))
+	}
+	id, err := strconv.Atoi(payload)
+	if err != nil {
+		return nil, BadCallbackData
+	}
+	user := bot.db.GetUser(id)
+	if user == nil {
+		return nil, fmt.Errorf("no user by that id: %d", id)
+	}
+	return user, nil
+}
+
+func (bot *Bot) handleCallbackBan(ctx *Context, payload string) (string, error) {
+	user, err := bot.callbackUser(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	if !user.Banned {
+		user.Ban(ctx.User, time.Time{}, suspiciousBanReason)
+		if err := bot.db.PutUser(user); err != nil {
+			return "", fmt.Errorf("failed to change user status: %v", err)
+		}
+	}
+	return bot.Tr(ctx).T("suspicious.banned", bot.Tr(ctx).NameAndTags(user)), nil
+}
+
+func (bot *Bot) handleCallbackRelease(ctx *Context, payload string) (string, error) {
+	user, err := bot.callbackUser(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	if err := bot.db.ReleaseClaims(user.ID); err != nil {
+		return "", fmt.Errorf("failed to release the claims: %v", err)
+	}
+	return bot.Tr(ctx).T("suspicious.released", bot.Tr(ctx).NameAndTags(user)), nil
+}
@@ -0,0 +1,191 @@
+package skyaway
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The lifecycle stages of an event which get announced in the group.
+const (
+	StageScheduled = "scheduled" // a public event has been scheduled
+	StageUpdated   = "updated"   // a public scheduled event has been changed
+	StageReminder  = "reminder"  // periodic countdown to the start
+	StageStarted   = "started"
+	StageOngoing   = "ongoing" // periodic countdown to the end
+	StageEnded     = "ended"
+	StageCancelled = "cancelled"
+)
+
+var stages = []string{
+	StageScheduled,
+	StageUpdated,
+	StageReminder,
+	StageStarted,
+	StageOngoing,
+	StageEnded,
+	StageCancelled,
+}
+
+const defaultTemplate = "*{{.Title}}*\n{{.Details}}{{with .Stats}}\n{{.}}{{end}}{{with .Leftover}}\n{{.}}{{end}}"
+
+// The data available to announcement templates.
+type announcement struct {
+	Stage    string
+	Title    string // translated title of the stage
+	Details  string // the event fields formatted as markdown
+	Stats    string // claim statistics, only for the pinned message
+	Leftover string // what happened to the unclaimed coins, only once ended
+	Coins    string
+	Duration string
+	StartsAt string
+	EndsAt   string
+	Event    *Event
+}
+
+func isStage(name string) bool {
+	for _, stage := range stages {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+func eventEndsAt(event *Event) time.Time {
+	if event.StartedAt.Valid {
+		return event.StartedAt.Time.Add(event.Duration.Duration)
+	}
+	return event.ScheduledAt.Time.Add(event.Duration.Duration)
+}
+
+func newAnnouncement(tr Translator, event *Event, stage string) *announcement {
+	a := &announcement{
+		Stage:    stage,
+		Title:    tr.T("title." + stage),
+		Details:  formatEventAsMarkdown(tr, event, true),
+		Coins:    tr.Coins(event.Coins),
+		Duration: tr.Duration(event.Duration.Duration),
+		EndsAt:   eventEndsAt(event).Format(timeFormat),
+		Event:    event,
+	}
+	if stage == StageEnded {
+		a.Leftover = describeLeftover(tr, event)
+	}
+	if event.StartedAt.Valid {
+		a.StartsAt = event.StartedAt.Time.Format(timeFormat)
+	} else {
+		a.StartsAt = event.ScheduledAt.Time.Format(timeFormat)
+	}
+	return a
+}
+
+// An event to render templates with when there is no real one.
+func sampleEvent() *Event {
+	return &Event{
+		ID:          1,
+		Coins:       100,
+		Duration:    NewDuration(2 * time.Hour),
+		ScheduledAt: NewNullTime(time.Now().Add(time.Hour)),
+	}
+}
+
+func parseTemplate(stage, text string) (*template.Template, error) {
+	tmpl, err := template.New(stage).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// render with a sample event to catch references to unknown fields
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newAnnouncement(defaultTranslator, sampleEvent(), stage)); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(buf.String()) == "" {
+		return nil, fmt.Errorf("the template renders to an empty message")
+	}
+	return tmpl, nil
+}
+
+// Loads the announcement templates. The templates stored in the database
+// override the ones from the config, which override the default one.
+func (bot *Bot) loadTemplates() error {
+	stored, err := bot.db.GetTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to get templates from db: %v", err)
+	}
+
+	templates := make(map[string]*template.Template)
+	for _, stage := range stages {
+		text, ok := stored[stage]
+		if !ok {
+			text, ok = bot.config.Templates[stage]
+		}
+		if !ok {
+			text = defaultTemplate
+		}
+
+		tmpl, err := parseTemplate(stage, text)
+		if err != nil {
+			return fmt.Errorf("bad template for '%s': %v", stage, err)
+		}
+		templates[stage] = tmpl
+	}
+
+	for name := range bot.config.Templates {
+		if !isStage(name) {
+			return fmt.Errorf("template for unknown stage '%s'", name)
+		}
+	}
+
+	bot.templatesLock.Lock()
+	bot.templates = templates
+	bot.templatesLock.Unlock()
+	return nil
+}
+
+// Returns the loaded template for the stage.
+func (bot *Bot) template(stage string) *template.Template {
+	bot.templatesLock.RLock()
+	defer bot.templatesLock.RUnlock()
+	return bot.templates[stage]
+}
+
+// Returns where the template for the stage comes from.
+func (bot *Bot) templateSource(stage string) (string, error) {
+	stored, err := bot.db.GetTemplates()
+	if err != nil {
+		return "", fmt.Errorf("failed to get templates from db: %v", err)
+	}
+	if _, ok := stored[stage]; ok {
+		return "database", nil
+	}
+	if _, ok := bot.config.Templates[stage]; ok {
+		return "config", nil
+	}
+	return "default", nil
+}
+
+func renderTemplate(tmpl *template.Template, a *announcement) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, a); err != nil {
+		return "", fmt.Errorf("failed to render the '%s' template: %v", a.Stage, err)
+	}
+	return buf.String(), nil
+}
+
+// Renders the announcement of the event at the stage in the language of the
+// group. Claim statistics are only gathered if `stats` is true.
+func (bot *Bot) renderAnnouncement(event *Event, stage string, stats bool) (string, error) {
+	tr := bot.groupTr()
+	a := newAnnouncement(tr, event, stage)
+	if stats {
+		var err error
+		if a.Stats, err = bot.formatEventStatsAsMarkdown(tr, event); err != nil {
+			return "", err
+		}
+	}
+	return renderTemplate(bot.template(stage), a)
+}
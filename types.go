@@ -2,12 +2,11 @@ package skyaway
 
 import (
 	"bytes"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
-	"strconv"
 )
 
 var nullString = []byte("null")
@@ -22,13 +21,23 @@ func NewDuration(d time.Duration) Duration {
 }
 
 type User struct {
-	ID        int    `json:"id"`
-	UserName  string `db:"username" json:"username,omitempty"`
-	FirstName string `db:"first_name" json:"first_name,omitempty"`
-	LastName  string `db:"last_name" json:"last_name,omitempty"`
-	Enlisted  bool   `json:"enlisted"`
-	Banned    bool   `json:"banned"`
-	Admin     bool   `json:"admin"`
+	ID           int            `json:"id"`
+	UserName     string         `db:"username" json:"username,omitempty"`
+	FirstName    string         `db:"first_name" json:"first_name,omitempty"`
+	LastName     string         `db:"last_name" json:"last_name,omitempty"`
+	Enlisted     bool           `json:"enlisted"`
+	Banned       bool           `json:"banned"`
+	Admin        bool           `json:"admin"`
+	Language     string         `json:"language,omitempty"` // empty for the telegram client language
+	Address      string         `json:"address,omitempty"`  // the default payout address
+	Verification string         `json:"verification,omitempty"`
+	BanReason    string         `db:"ban_reason" json:"ban_reason,omitempty"`
+	BannedBy     sql.NullInt64  `db:"banned_by" json:"-"`
+	BannedUntil  NullTime       `db:"banned_until" json:"banned_until,omitempty"`
+	FormerName   string         `db:"-" json:"-"` // the username the user was found by, if renamed since
+	ReferralCode sql.NullString `db:"referral_code" json:"-"`
+	Reminders    bool           `json:"reminders"`
+	Unreachable  bool           `json:"unreachable"` // cannot be messaged privately
 
 	exists bool
 }
@@ -39,33 +48,89 @@ type Participant struct {
 	UserName  string   `db:"username" json:"username,omitempty"`
 	Coins     int      `db:"coins" json:"coins"`
 	ClaimedAt NullTime `db:"claimed_at" json:"claimed_at,omitempty"`
+	Address   string   `db:"address" json:"address,omitempty"`
+	TxID      string   `db:"txid" json:"txid,omitempty"`
+	Held      bool     `db:"held" json:"held"`
+	OptedInAt NullTime `db:"opted_in_at" json:"opted_in_at,omitempty"`
+}
+
+// A participation of a user in a past event.
+type Participation struct {
+	Participant
+	StartedAt NullTime `db:"started_at"`
+}
+
+// The verification states of a user.
+const (
+	VerificationNone    = ""        // never challenged
+	VerificationPending = "pending" // has a challenge to answer
+	VerificationPassed  = "passed"
+	VerificationFailed  = "failed"
+	VerificationTimeout = "timeout"
+)
+
+// A verification challenge sent to a new member.
+type Challenge struct {
+	UserID    int       `db:"user_id"`
+	Answer    string    `db:"answer"`
+	ChatID    int64     `db:"chat_id"`
+	MessageID int       `db:"message_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// Conditions for a user to become a participant of an event, and how the
+// coins are split among the participants.
+type Eligibility struct {
+	RequireAddress bool   // only users with a saved payout address
+	Distribution   string // DistributionEqual, DistributionActivity or DistributionReferrals
+	MaxShare       int    // percent of the coins one participant may get, 0 for no limit
 }
 
 type TempUser struct {
-	ID       int    `db:"id"`
+	ID        int    `db:"id"`
+	UserName  string `db:"username"`
+	Messages  int    `db:"messages"`  // posted to the group recently
+	Referrals int    `db:"referrals"` // recorded recently
+}
+
+// The number of users the referrer brought to the group.
+type Referrer struct {
+	UserID    int    `db:"user_id"`
+	UserName  string `db:"username"`
+	Referrals int    `db:"referrals"`
+}
+
+// The number of messages the user posted to the group recently.
+type Activity struct {
+	UserID   int    `db:"user_id"`
 	UserName string `db:"username"`
+	Messages int    `db:"messages"`
 }
 
-func (u *User) NameAndTags() string {
-	var tags []string
-	if u.Banned {
-		tags = append(tags, "banned")
-	}
-	if u.Admin {
-		tags = append(tags, "admin")
+// Bans the user on behalf of the admin until the time, or permanently if the
+// time is zero.
+func (u *User) Ban(admin *User, until time.Time, reason string) {
+	u.Banned = true
+	u.BanReason = reason
+	u.BannedBy = sql.NullInt64{}
+	if admin != nil {
+		u.BannedBy = sql.NullInt64{Int64: int64(admin.ID), Valid: true}
 	}
-
-	// If username is hidden use userid
-	identifier := u.UserName
-	if identifier == "" {
-		identifier = strconv.Itoa(u.ID)
+	u.BannedUntil = NullTime{}
+	if !until.IsZero() {
+		u.BannedUntil = NewNullTime(until)
 	}
+}
 
-	if len(tags) > 0 {
-		return fmt.Sprintf("%s (%s)", identifier, strings.Join(tags, ", "))
-	}
+func (u *User) Unban() {
+	u.Banned = false
+	u.BanReason = ""
+	u.BannedBy = sql.NullInt64{}
+	u.BannedUntil = NullTime{}
+}
 
-	return identifier
+func (u *User) NameAndTags() string {
+	return defaultTranslator.NameAndTags(u)
 }
 
 func (u *User) Exists() bool {
@@ -78,14 +143,53 @@ type Chat struct {
 	Type  string `json:"type"`
 }
 
+// The question of a quiz event. Answers and options are kept one per line.
+type Quiz struct {
+	EventID   int           `db:"event_id" json:"event_id"`
+	Question  string        `json:"question"`
+	Answers   string        `json:"answers"`
+	Options   string        `json:"options"`
+	Match     string        `json:"match"`
+	Winners   int           `json:"winners"`
+	AnswerFor Duration      `db:"answer_for" json:"answer_for"`
+	MessageID sql.NullInt64 `db:"message_id" json:"message_id"`
+	Closed    bool          `json:"closed"`
+}
+
 type Event struct {
-	ID          int      `json:"id"`
-	Duration    Duration `json:"duration"`
-	ScheduledAt NullTime `db:"scheduled_at" json:"scheduled_at"`
-	StartedAt   NullTime `db:"started_at" json:"started_at"`
-	EndedAt     NullTime `db:"ended_at" json:"ended_at"`
-	Coins       int      `json:"coins"`
-	Surprise    bool     `json:"surpruse"`
+	ID          int           `json:"id"`
+	Duration    Duration      `json:"duration"`
+	ScheduledAt NullTime      `db:"scheduled_at" json:"scheduled_at"`
+	StartedAt   NullTime      `db:"started_at" json:"started_at"`
+	EndedAt     NullTime      `db:"ended_at" json:"ended_at"`
+	Coins       int           `json:"coins"`
+	Surprise    bool          `json:"surpruse"`
+	MessageID   sql.NullInt64 `db:"message_id" json:"message_id"`
+
+	// Users opt in during the registration before the start, instead of
+	// all enlisted users participating.
+	Registration Duration `db:"registration" json:"registration"`
+	Keyword      string   `db:"keyword" json:"keyword,omitempty"`
+
+	// What happens to the coins nobody has claimed by the end.
+	Rollover   string        `db:"rollover" json:"rollover"`
+	Leftover   sql.NullInt64 `db:"leftover" json:"leftover"`
+	RolledInto sql.NullInt64 `db:"rolled_into" json:"rolled_into"`
+
+	// The participants who have not claimed are reminded at the points
+	// configured with `claim_reminders`.
+	RemindedAt NullTime `db:"reminded_at" json:"reminded_at"`
+}
+
+// When the users can start opting in to the event.
+func (e *Event) RegistrationOpensAt() time.Time {
+	return e.ScheduledAt.Time.Add(-e.Registration.Duration)
+}
+
+// Whether the users can opt in to the event now.
+func (e *Event) RegistrationOpen() bool {
+	return e.Registration.Valid && e.ScheduledAt.Valid && !e.StartedAt.Valid &&
+		!time.Now().Before(e.RegistrationOpensAt())
 }
 
 func (d Duration) Value() (driver.Value, error) {
@@ -2,38 +2,44 @@ package skyaway
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 )
 
-func niceDuration(d time.Duration) string {
-	if d < 0 {
-		return d.String()
-	}
+const timeFormat = "Jan 2 2006, 15:04:05 -0700"
+const dateFormat = "Jan 2 2006"
 
-	var hours, minutes, seconds int
-	seconds = int(d.Seconds())
-	hours, seconds = seconds/3600, seconds%3600
-	minutes, seconds = seconds/60, seconds%60
+// The number of events listed by /events by default and at most.
+const (
+	defaultEventsListed = 10
+	maxEventsListed     = 100
+)
 
-	if hours > 0 {
-		if minutes > 0 {
-			return fmt.Sprintf("%dh%dm", hours, minutes)
-		} else {
-			return fmt.Sprintf("%dh", hours)
+// The number of intervals and the longest bar of the claim histogram.
+const (
+	histogramBuckets  = 10
+	histogramBarWidth = 20
+)
+
+// Draws the histogram as lines of bars, each starting with the offset of the
+// interval.
+func formatHistogram(tr Translator, histogram []int, width time.Duration) []string {
+	var max int
+	for _, n := range histogram {
+		if n > max {
+			max = n
 		}
-	} else {
-		if minutes > 0 {
-			if seconds > 0 {
-				return fmt.Sprintf("%dm%ds", minutes, seconds)
-			} else {
-				return fmt.Sprintf("%dm", minutes)
-			}
-		} else {
-			return fmt.Sprintf("%ds", seconds)
+	}
+
+	var lines []string
+	for i, n := range histogram {
+		var bar string
+		if max > 0 {
+			bar = strings.Repeat("█", n*histogramBarWidth/max)
 		}
+		lines = append(lines, fmt.Sprintf("`+%s` %s %d", tr.Duration(width*time.Duration(i)), bar, n))
 	}
+	return lines
 }
 
 func appendField(fields []string, name, format string, args ...interface{}) []string {
@@ -41,26 +47,26 @@ func appendField(fields []string, name, format string, args ...interface{}) []st
 	return append(fields, fmt.Sprintf("*%s*: %s", strings.Title(name), value))
 }
 
-func formatEventAsMarkdown(event *Event, public bool) string {
+func formatEventAsMarkdown(tr Translator, event *Event, public bool) string {
 	var fields []string
-	fields = appendField(fields, "coins", "%d", event.Coins)
+	fields = appendField(fields, tr.T("field.coins"), "%d", event.Coins)
 	if event.StartedAt.Valid {
-		fields = appendField(fields, "started", "%s (%s ago)",
-			event.StartedAt.Time.Format("Jan 2 2006, 15:04:05 -0700"),
-			niceDuration(time.Since(event.StartedAt.Time)),
-		)
+		fields = appendField(fields, tr.T("field.started"), "%s", tr.T("value.time_ago",
+			event.StartedAt.Time.Format(timeFormat),
+			tr.Duration(time.Since(event.StartedAt.Time)),
+		))
 	} else {
-		fields = appendField(fields, "will start", "%s (in %s)",
-			event.ScheduledAt.Time.Format("Jan 2 2006, 15:04:05 -0700"),
-			niceDuration(time.Until(event.ScheduledAt.Time)),
-		)
+		fields = appendField(fields, tr.T("field.will_start"), "%s", tr.T("value.time_in",
+			event.ScheduledAt.Time.Format(timeFormat),
+			tr.Duration(time.Until(event.ScheduledAt.Time)),
+		))
 	}
 
 	if event.EndedAt.Valid {
-		fields = appendField(fields, "duration", "%s (ended %s ago)",
-			niceDuration(event.Duration.Duration),
-			niceDuration(time.Since(event.EndedAt.Time)),
-		)
+		fields = appendField(fields, tr.T("field.duration"), "%s", tr.T("value.ended_ago",
+			tr.Duration(event.Duration.Duration),
+			tr.Duration(time.Since(event.EndedAt.Time)),
+		))
 	} else {
 		var endsAt time.Time
 		if event.StartedAt.Valid {
@@ -68,28 +74,23 @@ func formatEventAsMarkdown(event *Event, public bool) string {
 		} else {
 			endsAt = event.ScheduledAt.Time.Add(event.Duration.Duration)
 		}
-		fields = appendField(fields, "duration", "%s (ends in %s)",
-			niceDuration(event.Duration.Duration),
-			niceDuration(time.Until(endsAt)),
-		)
+		fields = appendField(fields, tr.T("field.duration"), "%s", tr.T("value.ends_in",
+			tr.Duration(event.Duration.Duration),
+			tr.Duration(time.Until(endsAt)),
+		))
 	}
 
 	if !public {
-		fields = appendField(fields, "surprise", "%t", event.Surprise)
+		surprise := tr.T("value.no")
+		if event.Surprise {
+			surprise = tr.T("value.yes")
+		}
+		fields = appendField(fields, tr.T("field.surprise"), "%s", surprise)
 	}
 
 	return strings.Join(fields, "\n")
 }
 
-func parseDuration(args string) (time.Duration, error) {
-	hours, err := strconv.ParseFloat(args, 64)
-	if err == nil {
-		return time.Second * time.Duration(hours*3600), nil
-	}
-
-	return time.ParseDuration(args)
-}
-
 func (bot *Bot) SetCommandHandler(admin bool, command string, handler CommandHandler) {
 	if admin {
 		bot.adminCommandHandlers[command] = handler